@@ -2,9 +2,15 @@ package aromalang
 
 import (
 	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/conversion"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"text/scanner"
+	"unicode"
 )
 
 type AST struct {
@@ -21,6 +27,34 @@ func (a *AST) Metadata() map[string]string {
 	return md
 }
 
+// Title returns the recipe's "title" metadata, or the name of the
+// recipe's file without its extension if it has none.
+func (a *AST) Title() string {
+	if t := strings.TrimSpace(a.Metadata()["title"]); t != "" {
+		return t
+	}
+	base := path.Base(filepath.ToSlash(a.Filename))
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// Servings returns the number of servings in the recipe's "servings"
+// metadata. Cooklang allows several alternatives separated by |, in
+// which case the first one is used.
+func (a *AST) Servings() (float64, bool) {
+	fields := strings.FieldsFunc(a.Metadata()["servings"], func(r rune) bool {
+		return r == '|' || unicode.IsSpace(r)
+	})
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	n, err := conversion.Numeral(fields[0]).Float()
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
 func (a *AST) ReportError(s *scanner.Scanner, msg string) {
 	a.Errors = append(a.Errors, ParseError{
 		Position: s.Position,
@@ -134,6 +168,26 @@ func (s Step) HasInstructions() bool {
 	return false
 }
 
+// Text returns the step as plain text, with ingredients and cookware
+// replaced by their names and timers by their durations. Comments and
+// metadata are left out.
+func (s Step) Text() string {
+	b := strings.Builder{}
+	for _, c := range s.Components {
+		switch c := c.(type) {
+		case Instruction:
+			b.WriteString(c.Instruction)
+		case Ingredient:
+			b.WriteString(c.Name)
+		case Cookware:
+			b.WriteString(c.Name)
+		case Timer:
+			b.WriteString(strings.TrimSpace(c.Magnitude + " " + c.Unit))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
 func (s Step) Ingredients() []Ingredient {
 	list := []Ingredient{}
 	for _, c := range s.Components {
@@ -193,6 +247,13 @@ func (i Ingredient) String() string {
 	return fmt.Sprintf(`(ingredient "%s" {%s})`, i.Name, strings.Join(args, " "))
 }
 
+// Key returns the canonical key of the ingredient's name, which is
+// shared by other spellings of the same ingredient such as "Eggs" and
+// "egg". See [canonical.Dictionary] to include synonyms.
+func (i Ingredient) Key() string {
+	return canonical.Key(i.Name)
+}
+
 type Cookware struct {
 	Base
 	Name string
@@ -222,3 +283,43 @@ type Metadata struct {
 func (m Metadata) String() string {
 	return fmt.Sprintf(`(metadata "%s" "%s")`, m.Key, m.Value)
 }
+
+// MetadataOrder is the order in which [SortMetadata] puts well-known
+// metadata keys.
+var MetadataOrder = []string{
+	"title",
+	"description",
+	"author",
+	"source",
+	"servings",
+	"yield",
+	"tags",
+	"category",
+	"course",
+	"cuisine",
+	"diet",
+	"prep time",
+	"cook time",
+	"time",
+	"image",
+}
+
+// SortMetadata returns a copy of md with the keys in [MetadataOrder]
+// first and in that order, followed by the other keys in the order they
+// were in.
+func SortMetadata(md []Metadata) []Metadata {
+	res := append([]Metadata{}, md...)
+	sort.SliceStable(res, func(i, j int) bool {
+		return metadataRank(res[i].Key) < metadataRank(res[j].Key)
+	})
+	return res
+}
+
+func metadataRank(key string) int {
+	for i, k := range MetadataOrder {
+		if strings.EqualFold(k, strings.TrimSpace(key)) {
+			return i
+		}
+	}
+	return len(MetadataOrder)
+}
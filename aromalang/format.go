@@ -0,0 +1,244 @@
+package aromalang
+
+import (
+	"sort"
+	"strings"
+)
+
+// FormatTokens writes a tokenized aromalang recipe in the canonical
+// style, which is the layout of [AST.String]. It works from the tokens,
+// including the whitespace and line breaks that [ParseTokens] skips,
+// so that comments are kept and blank lines between the components of
+// a step stay. The metadata of the recipe is sorted as by
+// [SortMetadata].
+func FormatTokens(filename string, tokens []Token) (string, error) {
+	if _, err := ParseTokens(filename, tokens); err != nil {
+		return "", err
+	}
+
+	pos := 0
+	var nodes []node
+	for pos < len(tokens) {
+		n, ok := readNode(tokens, &pos)
+		if !ok {
+			break
+		}
+		nodes = append(nodes, n)
+	}
+
+	b := strings.Builder{}
+	for _, n := range nodes {
+		b.WriteString(n.recipe() + "\n")
+	}
+	return b.String(), nil
+}
+
+// node is a value in an aromalang source, which is a single token or a
+// list, map or element with the values within it.
+type node struct {
+	tok      Token
+	children []node
+	// blank is set for values that come after a blank line.
+	blank bool
+}
+
+// readNode reads the value that starts at or after pos, and returns
+// false if there are only whitespace and closing tokens left.
+func readNode(tokens []Token, pos *int) (node, bool) {
+	newlines := 0
+	for ; *pos < len(tokens); *pos++ {
+		switch tok := tokens[*pos]; tok.Type {
+		case TokenNewLine:
+			newlines++
+			continue
+		case TokenWhitespace:
+			newlines += strings.Count(tok.Value, "\n")
+			continue
+		}
+		break
+	}
+	if *pos >= len(tokens) {
+		return node{}, false
+	}
+
+	n := node{tok: tokens[*pos], blank: newlines > 1}
+	*pos++
+
+	var end TokenType
+	switch n.tok.Type {
+	case TokenLParen:
+		end = TokenRParen
+	case TokenLBrace:
+		end = TokenRBrace
+	case TokenLBracket:
+		end = TokenRBracket
+	case TokenRParen, TokenRBrace, TokenRBracket:
+		return node{}, false
+	default:
+		return n, true
+	}
+
+	for {
+		child, ok := readNode(tokens, pos)
+		if !ok {
+			break
+		}
+		n.children = append(n.children, child)
+	}
+	if *pos < len(tokens) && tokens[*pos].Type == end {
+		*pos++
+	}
+	return n, true
+}
+
+// is returns true if n is an element called name with the given kinds
+// of values after the name.
+func (n node) is(name string, kinds ...TokenType) bool {
+	if n.tok.Type != TokenLParen || len(n.children) != len(kinds)+1 || n.children[0].tok.Value != name {
+		return false
+	}
+	for i, k := range kinds {
+		if n.children[i+1].tok.Type != k {
+			return false
+		}
+	}
+	return true
+}
+
+// recipe writes a recipe with its metadata on lines of their own and
+// a blank line between its steps.
+func (n node) recipe() string {
+	if !n.is("recipe", TokenLBrace, TokenLBracket) {
+		return n.inline()
+	}
+
+	md, steps := n.children[1], n.children[2]
+	b := strings.Builder{}
+	b.WriteString("(recipe {")
+	if pairs := md.pairs(); len(pairs) != 0 {
+		b.WriteByte('\n')
+		for _, p := range pairs {
+			b.WriteString("\t" + p[0].inline() + " " + p[1].inline() + "\n")
+		}
+	}
+	b.WriteString("}\n[")
+	for _, s := range steps.children {
+		b.WriteString("\n" + s.step() + "\n")
+	}
+	b.WriteString("])")
+	return b.String()
+}
+
+// pairs returns the keys and values of a map, with the well-known keys
+// first.
+func (n node) pairs() [][2]node {
+	var pairs [][2]node
+	for i := 0; i+1 < len(n.children); i += 2 {
+		pairs = append(pairs, [2]node{n.children[i], n.children[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return metadataRank(pairs[i][0].tok.Value) < metadataRank(pairs[j][0].tok.Value)
+	})
+	return pairs
+}
+
+// step writes a step with each of its components on a line of its own.
+func (n node) step() string {
+	if !n.is("step", TokenLBrace, TokenLBracket) {
+		return n.inline()
+	}
+
+	b := strings.Builder{}
+	b.WriteString("(step " + n.children[1].inline() + "\n\t[")
+	for i, c := range n.children[2].children {
+		if i != 0 {
+			if c.blank {
+				b.WriteByte('\n')
+			}
+			b.WriteString("\n\t")
+		}
+		b.WriteString(c.inline())
+	}
+	b.WriteString("])")
+	return b.String()
+}
+
+// inline writes the value on a single line.
+func (n node) inline() string {
+	var open, close string
+	switch n.tok.Type {
+	case TokenString:
+		return quote(n.tok.Value)
+	case TokenAtom:
+		return ":" + n.tok.Value
+	case TokenLParen:
+		open, close = "(", ")"
+	case TokenLBrace:
+		open, close = "{", "}"
+	case TokenLBracket:
+		open, close = "[", "]"
+	default:
+		return n.tok.Value
+	}
+
+	values := make([]string, 0, len(n.children))
+	for _, c := range n.children {
+		values = append(values, c.inline())
+	}
+	return open + strings.Join(values, " ") + close
+}
+
+// Format writes the recipe as aromalang in the canonical style of
+// [FormatTokens]. Unlike [AST.String], it keeps comments and the
+// metadata of steps, and escapes strings so that they are read back
+// the same.
+func Format(ast *AST) string {
+	b := strings.Builder{}
+	b.WriteString("(recipe {")
+	if len(ast.Recipe.Metadata) != 0 {
+		b.WriteByte('\n')
+	}
+	for _, md := range ast.Recipe.Metadata {
+		b.WriteString("\t" + quote(md.Key) + " " + quote(md.Value) + "\n")
+	}
+	b.WriteString("}\n[")
+	for _, step := range ast.Recipe.Steps {
+		b.WriteString("\n" + formatStep(step) + "\n")
+	}
+	b.WriteString("])\n")
+	return b.String()
+}
+
+func formatStep(s Step) string {
+	var md, comps []string
+	for _, c := range s.Components {
+		switch c := c.(type) {
+		case Metadata:
+			md = append(md, quote(c.Key)+" "+quote(c.Value))
+		case Instruction:
+			comps = append(comps, "(instruction "+quote(c.Instruction)+")")
+		case Comment:
+			comps = append(comps, "(comment "+quote(c.Comment)+")")
+		case Ingredient:
+			var args []string
+			if c.Quantity != "" {
+				args = append(args, ":quantity "+quote(c.Quantity))
+			}
+			if c.Unit != "" {
+				args = append(args, ":unit "+quote(c.Unit))
+			}
+			comps = append(comps, "(ingredient "+quote(c.Name)+" {"+strings.Join(args, " ")+"})")
+		case Cookware:
+			comps = append(comps, "(cookware "+quote(c.Name)+")")
+		case Timer:
+			comps = append(comps, "(timer "+quote(c.Name)+" {:magnitude "+quote(c.Magnitude)+" :unit "+quote(c.Unit)+"})")
+		}
+	}
+	return "(step {" + strings.Join(md, " ") + "}\n\t[" + strings.Join(comps, "\n\t") + "])"
+}
+
+// quote returns s as an aromalang string. Unlike Go strings, only
+// backslashes and double quotes are escaped.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,46 @@
+package aromalang
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatTokens(t *testing.T) {
+	const src = `(recipe {"servings" "2" "title" "Tea \"Earl\" Grey"} [(step {"oven" "200"} [(comment "hot") (instruction "Boil")
+
+		(ingredient "water" {:quantity "1" :unit "l"})]) (step {} [(timer "steep" {:magnitude "3" :unit "minutes"})])])`
+	const expected = `(recipe {
+	"title" "Tea \"Earl\" Grey"
+	"servings" "2"
+}
+[
+(step {"oven" "200"}
+	[(comment "hot")
+	(instruction "Boil")
+
+	(ingredient "water" {:quantity "1" :unit "l"})])
+
+(step {}
+	[(timer "steep" {:magnitude "3" :unit "minutes"})])
+])
+`
+
+	tokens, errs := Tokenize("tea.aroma", strings.NewReader(src))
+	if len(errs) != 0 {
+		t.Error(errs)
+		t.FailNow()
+	}
+	formatted, err := FormatTokens("tea.aroma", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if formatted != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, formatted)
+	}
+
+	tokens, _ = Tokenize("tea.aroma", strings.NewReader(`(recipe {"title" 2} [])`))
+	if _, err := FormatTokens("tea.aroma", tokens); err == nil {
+		t.Errorf("expected an invalid recipe not to be formatted")
+	}
+}
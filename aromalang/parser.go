@@ -118,7 +118,7 @@ func (p *parser) parseElement() (Component, error) {
 
 		return r, nil
 	case "step":
-		return Step{}, nil
+		return Step{Base: Base{Pos: tok.Position}}, nil
 	default:
 		return nil, NewErrorf(tok.Position, "unknowns identifier '%s'", tok.Value)
 	}
@@ -147,7 +147,7 @@ func (p *parser) parseMap() ([]Metadata, error) {
 		value := p.Next()
 		// TODO: Change this, values should be able to be of many types, including dynamic types.
 		if value.Type != TokenString {
-			return nil, NewErrorf(p.curr.Position, "metadata values must be a string, got %s", key.Type)
+			return nil, NewErrorf(p.curr.Position, "metadata values must be a string, got %s", value.Type)
 		}
 
 		elems = append(elems, Metadata{
@@ -259,6 +259,15 @@ func (p *parser) parseComponent() (Component, error) {
 			Base:        Base{Pos: c.Position},
 			Instruction: tok.Value,
 		}
+	case "comment":
+		tok := p.Next()
+		if err := checkType(TokenString, tok); err != nil {
+			return nil, err
+		}
+		comp = Comment{
+			Base:    Base{Pos: c.Position},
+			Comment: tok.Value,
+		}
 	case "cookware":
 		tok := p.Next()
 		if err := checkType(TokenString, tok); err != nil {
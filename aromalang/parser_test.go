@@ -22,3 +22,61 @@ func TestParse(t *testing.T) {
 
 	fmt.Println(ast)
 }
+
+func TestParseCommentAndString(t *testing.T) {
+	const src = `(recipe {"title" "Tea \"Earl\" Grey"} [(step {"oven" "200"} [(comment "hot") (instruction "Boil \\ steep.")])])`
+	ast, err := Parse("tea.aroma", strings.NewReader(src))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	step := ast.Recipe.Steps[0]
+	if len(step.Components) != 3 {
+		t.Errorf("expected metadata, comment and instruction, got %v", step.Components)
+		t.FailNow()
+	}
+	if c, ok := step.Components[1].(Comment); !ok || c.Comment != "hot" {
+		t.Errorf("expected comment, got %v", step.Components[1])
+	}
+
+	again, err := Parse("tea.aroma", strings.NewReader(Format(ast)))
+	if err != nil {
+		t.Errorf("expected Format to return valid aromalang, got %v:\n%s", err, Format(ast))
+		t.FailNow()
+	}
+	if Format(again) != Format(ast) || again.Title() != `Tea "Earl" Grey` {
+		t.Errorf("expected the recipe to survive Format, got:\n%s", Format(again))
+	}
+}
+
+func TestParseMetadataError(t *testing.T) {
+	_, err := Parse("tea.aroma", strings.NewReader(`(recipe {"servings" 4} [])`))
+	if err == nil || !strings.HasSuffix(err.Error(), "metadata values must be a string, got Numeral") {
+		t.Errorf("expected the type of the value in the error, got %v", err)
+	}
+}
+
+func TestParseStepPosition(t *testing.T) {
+	ast, err := Parse("tea.aroma", strings.NewReader("(recipe {} [\n\t(step {} [(instruction \"Boil.\")])])"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	pos := ast.Recipe.Steps[0].Pos
+	if pos.Line != 2 || pos.Column != 3 {
+		t.Errorf("expected the step at 2:3, got %s", pos)
+	}
+}
+
+func TestSortMetadata(t *testing.T) {
+	md := SortMetadata([]Metadata{{Key: "z"}, {Key: "servings"}, {Key: "a"}, {Key: "Title"}})
+	keys := []string{}
+	for _, m := range md {
+		keys = append(keys, m.Key)
+	}
+	if strings.Join(keys, ",") != "Title,servings,z,a" {
+		t.Errorf("expected known keys first, got %v", keys)
+	}
+}
@@ -0,0 +1,91 @@
+package aromalang
+
+import (
+	"github.com/dememorized/cook/internal/conversion"
+	"strings"
+)
+
+// Duration returns how long the timer runs for.
+func (t Timer) Duration() (conversion.TimeDiff, error) {
+	return conversion.ParseDuration(t.Magnitude, t.Unit)
+}
+
+func (s Step) Timers() []Timer {
+	list := []Timer{}
+	for _, c := range s.Components {
+		t, ok := c.(Timer)
+		if ok {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+func (s Step) Cookware() []Cookware {
+	list := []Cookware{}
+	for _, c := range s.Components {
+		cw, ok := c.(Cookware)
+		if ok {
+			list = append(list, cw)
+		}
+	}
+	return list
+}
+
+// Metadata keys that are used for durations of a recipe, in order of
+// preference.
+var (
+	TotalTimeKeys = []string{"time", "total time", "time required", "duration"}
+	PrepTimeKeys  = []string{"prep time", "prep_time", "preparation time"}
+	CookTimeKeys  = []string{"cook time", "cook_time", "cooking time"}
+)
+
+// MetadataDuration returns the duration in the first of keys that is
+// present in the recipe's metadata and can be parsed as a duration.
+func (a *AST) MetadataDuration(keys ...string) (conversion.TimeDiff, bool) {
+	md := map[string]string{}
+	for k, v := range a.Metadata() {
+		md[strings.ToLower(k)] = v
+	}
+
+	for _, k := range keys {
+		v, ok := md[k]
+		if !ok {
+			continue
+		}
+		if d, err := conversion.ParseDurationText(v); err == nil {
+			return d, true
+		}
+	}
+	return conversion.TimeDiff{}, false
+}
+
+// TotalTime returns the time it takes to make the recipe. It is read
+// from the recipe's metadata if available, otherwise it is the sum of
+// the prep and cook times or, failing that, the sum of all timers in
+// the recipe. Returns false if the recipe has no time information.
+func (a *AST) TotalTime() (conversion.TimeDiff, bool) {
+	if d, ok := a.MetadataDuration(TotalTimeKeys...); ok {
+		return d, true
+	}
+
+	prep, okPrep := a.MetadataDuration(PrepTimeKeys...)
+	cook, okCook := a.MetadataDuration(CookTimeKeys...)
+	if okPrep || okCook {
+		return prep.Add(cook), true
+	}
+
+	var total conversion.TimeDiff
+	found := false
+	for _, step := range a.Recipe.Steps {
+		for _, t := range step.Timers() {
+			d, err := t.Duration()
+			if err != nil {
+				continue
+			}
+			total = total.Add(d)
+			found = true
+		}
+	}
+	return total, found
+}
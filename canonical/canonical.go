@@ -0,0 +1,88 @@
+// Package canonical maps the many ways of writing an ingredient's name
+// to a single key, so that "Egg", "eggs" and "large eggs" can be
+// recognized as the same ingredient.
+package canonical
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Key returns the canonical key for an ingredient name without any
+// synonyms. Case is folded, whitespace and punctuation collapsed and
+// the last word of the name is made singular.
+func Key(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '\'' && r != '-' && r != '&')
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	words[len(words)-1] = Singular(words[len(words)-1])
+	return strings.Join(words, " ")
+}
+
+// irregular lists plural forms that aren't covered by the rules in
+// Singular, and words ending with s that aren't plural.
+var irregular = map[string]string{
+	"leaves":     "leaf",
+	"halves":     "half",
+	"loaves":     "loaf",
+	"knives":     "knife",
+	"calves":     "calf",
+	"geese":      "goose",
+	"mice":       "mouse",
+	"teeth":      "tooth",
+	"feet":       "foot",
+	"cookies":    "cookie",
+	"brownies":   "brownie",
+	"smoothies":  "smoothie",
+	"veggies":    "veggie",
+	"pies":       "pie",
+	"ties":       "tie",
+	"shoes":      "shoe",
+	"molasses":   "molasses",
+	"hummus":     "hummus",
+	"couscous":   "couscous",
+	"asparagus":  "asparagus",
+	"citrus":     "citrus",
+	"swiss":      "swiss",
+	"series":     "series",
+	"species":    "species",
+	"grits":      "grits",
+	"oats":       "oats",
+	"greens":     "greens",
+	"anise":      "anise",
+	"mayonnaise": "mayonnaise",
+}
+
+// Singular returns the singular form of an English noun using a few
+// simple rules, which is good enough for the names of ingredients.
+func Singular(word string) string {
+	if s, ok := irregular[word]; ok {
+		return s
+	}
+
+	switch {
+	case len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "sses"),
+		strings.HasSuffix(word, "ches"),
+		strings.HasSuffix(word, "shes"),
+		strings.HasSuffix(word, "xes"),
+		strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"),
+		strings.HasSuffix(word, "us"),
+		strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	default:
+		return word
+	}
+}
@@ -0,0 +1,66 @@
+package canonical
+
+import (
+	"bytes"
+	_ "embed"
+	"testing"
+)
+
+//go:embed testdata/synonyms.txt
+var synonyms []byte
+
+func TestKey(t *testing.T) {
+	tests := map[string]string{
+		"Eggs":                   "egg",
+		"  sea   salt ":          "sea salt",
+		"tomatoes":               "tomato",
+		"Blueberries":            "blueberry",
+		"peaches":                "peach",
+		"bay leaves":             "bay leaf",
+		"couscous":               "couscous",
+		"Brussels sprouts":       "brussels sprout",
+		"flour, all-purpose":     "flour all-purpose",
+		"chocolate chip cookies": "chocolate chip cookie",
+		"red lentils":            "red lentil",
+		"tortilla chips":         "tortilla chip",
+	}
+
+	for name, expected := range tests {
+		if k := Key(name); k != expected {
+			t.Errorf("expected key for %q to be %q, got %q", name, expected, k)
+		}
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	d, err := LoadSynonyms(bytes.NewReader(synonyms))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	for _, name := range []string{"egg", "Eggs", "large eggs", "large egg", "hen's eggs"} {
+		if k := d.Key(name); k != "egg" {
+			t.Errorf("expected %q to be canonicalized to egg, got %q", name, k)
+		}
+	}
+	if d.Key("spring onions") != "scallion" || d.Display("scallion") != "scallion" {
+		t.Errorf("expected spring onions to be a scallion, got %q", d.Key("spring onions"))
+	}
+
+	var empty *Dictionary
+	if empty.Key("Eggs") != "egg" {
+		t.Errorf("expected nil dictionary to use the default rules")
+	}
+	if err := empty.Add("scallion", "spring onion"); err == nil || empty.Key("spring onions") != "spring onion" {
+		t.Errorf("expected adding to a nil dictionary to fail, got %v", err)
+	}
+
+	var zero Dictionary
+	if err := zero.Add("scallion", "spring onion"); err != nil {
+		t.Error(err)
+	}
+	if zero.Key("spring onions") != "scallion" {
+		t.Errorf("expected synonyms to be added to the zero value, got %q", zero.Key("spring onions"))
+	}
+}
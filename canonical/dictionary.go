@@ -0,0 +1,124 @@
+package canonical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Name is an ingredient's name as written in a recipe together with
+// its canonical key.
+type Name struct {
+	Key     string
+	Display string
+}
+
+// Dictionary canonicalizes ingredient names using user provided
+// synonyms on top of the rules in [Key]. A nil Dictionary has no
+// synonyms and can be looked up in, but [Dictionary.Add] returns an
+// error for it.
+type Dictionary struct {
+	synonyms map[string]string
+	display  map[string]string
+}
+
+func NewDictionary() *Dictionary {
+	return &Dictionary{
+		synonyms: map[string]string{},
+		display:  map[string]string{},
+	}
+}
+
+// LoadSynonyms reads a synonym file. Like Cooklang's aisle
+// configuration, every line lists the names of an ingredient separated
+// by |, with the first name being the canonical one:
+//
+//	egg | large eggs | hen's eggs
+//	scallion | spring onion | green onion
+//
+// Empty lines and lines starting with # are ignored.
+func LoadSynonyms(r io.Reader) (*Dictionary, error) {
+	d := NewDictionary()
+
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		names := strings.Split(line, "|")
+		if strings.TrimSpace(names[0]) == "" {
+			return nil, fmt.Errorf("line %d: missing canonical name", lineNo)
+		}
+		d.Add(names[0], names[1:]...)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Add registers synonyms as other names of canonical. Adding a synonym
+// that already exists replaces it. It returns an error if d is nil.
+func (d *Dictionary) Add(canonical string, synonyms ...string) error {
+	if d == nil {
+		return fmt.Errorf("cannot add synonyms of %q to a nil dictionary", strings.TrimSpace(canonical))
+	}
+	if d.synonyms == nil {
+		d.synonyms = map[string]string{}
+		d.display = map[string]string{}
+	}
+	key := d.Key(canonical)
+	d.display[key] = strings.TrimSpace(canonical)
+
+	for _, syn := range synonyms {
+		k := Key(syn)
+		if k == "" || k == key {
+			continue
+		}
+		d.synonyms[k] = key
+	}
+	return nil
+}
+
+// Key returns the canonical key for name.
+func (d *Dictionary) Key(name string) string {
+	k := Key(name)
+	if d == nil {
+		return k
+	}
+	if syn, ok := d.synonyms[k]; ok {
+		return syn
+	}
+	return k
+}
+
+// Name returns the canonical key of name together with the name itself.
+func (d *Dictionary) Name(name string) Name {
+	return Name{
+		Key:     d.Key(name),
+		Display: strings.TrimSpace(name),
+	}
+}
+
+// Display returns the preferred way of writing the ingredient with the
+// given key, which is the canonical name from the synonym file if there
+// is one, and key otherwise.
+func (d *Dictionary) Display(key string) string {
+	if d != nil {
+		if name, ok := d.display[key]; ok {
+			return name
+		}
+	}
+	return key
+}
+
+func (d *Dictionary) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.synonyms)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/cooklang"
+	"github.com/dememorized/cook/internal/load"
+	"io"
+	"path"
+	"strings"
+)
+
+func init() {
+	register(command{
+		Name:    "convert",
+		Summary: "convert a recipe between Cooklang and aromalang",
+		Run:     runConvert,
+	})
+}
+
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("convert", "file", stderr)
+	output := fs.String("o", "", "write to `file`, in the syntax given by its extension")
+	to := fs.String("to", "", "`syntax` to convert to when writing to stdout: cook or aroma (default: the other one)")
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	ext := "." + strings.TrimPrefix(*to, ".")
+	switch {
+	case *output != "":
+		ext = path.Ext(*output)
+	case *to == "" && strings.EqualFold(path.Ext(files[0]), load.ExtCooklang):
+		ext = load.ExtAroma
+	case *to == "":
+		ext = load.ExtCooklang
+	}
+
+	var format func(*aromalang.AST) string
+	switch strings.ToLower(ext) {
+	case load.ExtCooklang:
+		format = cooklang.Format
+	case load.ExtAroma:
+		format = aromalang.Format
+	default:
+		report(stderr, fmt.Errorf("cannot convert to unknown format '%s'", ext))
+		return 2
+	}
+
+	ast, err := parseFile(files[0])
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	err = writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, format(ast))
+		return err
+	})
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type diffOp struct {
+	kind byte
+	text string
+	// a and b are the line indexes in the old and new text before
+	// the operation.
+	a, b int
+}
+
+// diffContext is the number of unchanged lines around every change.
+const diffContext = 3
+
+// unifiedDiff returns the differences between the old and new text in
+// the unified diff format, or an empty string if they are equal.
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	a, b := splitLines(oldText), splitLines(newText)
+	ops := diffLines(a, b)
+
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+
+	out := strings.Builder{}
+	out.WriteString("--- " + oldName + "\n+++ " + newName + "\n")
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-diffContext, 0)
+		last := changes[i]
+		for i++; i < len(changes) && changes[i]-last <= 2*diffContext; i++ {
+			last = changes[i]
+		}
+		end := min(last+diffContext+1, len(ops))
+
+		writeHunk(&out, ops[start:end])
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, ops []diffOp) {
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	aStart, bStart := ops[0].a, ops[0].b
+	if aCount != 0 {
+		aStart++
+	}
+	if bCount != 0 {
+		bStart++
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, op := range ops {
+		out.WriteByte(op.kind)
+		out.WriteString(op.text + "\n")
+	}
+}
+
+// diffLines finds the operations that turn a into b using the longest
+// common subsequence of lines, which is fast enough for recipes.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], a: i, b: j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: a[i], a: i, b: j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j], a: i, b: j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
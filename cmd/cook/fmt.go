@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/dememorized/cook/internal/load"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	register(command{
+		Name:    "fmt",
+		Summary: "format recipes in the canonical style",
+		Run:     runFmt,
+	})
+}
+
+func runFmt(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("fmt", "path...", stderr)
+	write := fs.Bool("w", false, "write the result to the source file instead of stdout")
+	diff := fs.Bool("d", false, "print diffs instead of the formatted source")
+	list := fs.Bool("l", false, "list the files whose formatting differs")
+	paths, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	files, err := recipeFiles(paths)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	code := 0
+	for _, name := range files {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			report(stderr, err)
+			code = 1
+			continue
+		}
+		res, err := load.Format(name, src)
+		if err != nil {
+			report(stderr, err)
+			code = 1
+			continue
+		}
+
+		changed := !bytes.Equal(src, res)
+		if *list && changed {
+			fmt.Fprintln(stdout, name)
+		}
+		if *diff {
+			io.WriteString(stdout, unifiedDiff(name+".orig", name, string(src), string(res)))
+		}
+		if *write && changed {
+			if err := os.WriteFile(name, res, 0o644); err != nil {
+				report(stderr, err)
+				code = 1
+			}
+		}
+		if !*list && !*diff && !*write {
+			stdout.Write(res)
+		}
+	}
+	return code
+}
+
+// recipeFiles expands the directories in paths to the recipes within
+// them. Files that are given explicitly are kept even if they don't
+// look like recipes, so that they are reported.
+func recipeFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		err = filepath.WalkDir(p, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && load.Supported(name) {
+				files = append(files, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
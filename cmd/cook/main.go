@@ -0,0 +1,151 @@
+// Command cook parses, renders and converts recipes written in
+// Cooklang (.cook) or aromalang (.aroma).
+//
+// Usage:
+//
+//	cook render [--format html] [--scale n] [--units metric] [-o file] file
+//	cook convert file.cook -o file.aroma
+//	cook parse [--ast] file
+//	cook fmt [-w] [-d] [-l] path...
+//	cook shop [--aisles file] [--pantry file] [--format text] recipe[:scale]... plan...
+//	cook serve [--addr localhost:8080] [dir]
+//	cook run [--scale n] file
+//	cook search [-C dir] [--index file] query...
+//	cook suggest [--pantry file] [dir]
+//	cook substitute [--db file] --avoid ingredient,... [--rewrite] file
+//
+// The syntax of a recipe is detected by its file extension.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/load"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command is a subcommand of cook. Run returns the exit code.
+type command struct {
+	Name    string
+	Summary string
+	Run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]command{}
+
+func register(c command) {
+	commands[c.Name] = c
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	c, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "cook: unknown command '%s'\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	return c.Run(args[1:], stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: cook <command> [flags] [files]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].Summary)
+	}
+	fmt.Fprintln(w, "\nRun 'cook <command> -h' for the flags of a command.")
+}
+
+// newFlagSet returns a flag set for the named command which writes its
+// errors and usage to stderr.
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: cook %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, allowing flags to come after the positional
+// arguments as in 'cook convert in.cook -o out.aroma', and returns the
+// positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// flagExit is the exit code after a failure to parse flags, which is
+// zero if help was requested.
+func flagExit(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return 2
+}
+
+// parseFile reads and parses the recipe at name.
+func parseFile(name string) (*aromalang.AST, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return load.Parse(name, f)
+}
+
+// report writes err to w, with parse errors as file:line:col: message
+// so that editors can jump to them.
+func report(w io.Writer, err error) {
+	var pe *aromalang.ParseError
+	if errors.As(err, &pe) {
+		fmt.Fprintln(w, formatParseError(*pe))
+		return
+	}
+	var pv aromalang.ParseError
+	if errors.As(err, &pv) {
+		fmt.Fprintln(w, formatParseError(pv))
+		return
+	}
+	fmt.Fprintf(w, "cook: %s\n", err)
+}
+
+func formatParseError(e aromalang.ParseError) string {
+	pos := e.Position
+	return fmt.Sprintf("%s:%d:%d: %s", pos.Filename, pos.Line, pos.Column, strings.TrimSpace(e.Message))
+}
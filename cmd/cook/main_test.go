@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runTest(t *testing.T, args ...string) (string, string, int) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	code := run(args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestRender(t *testing.T) {
+	out, errOut, code := runTest(t, "render", "--format", "markdown", "testdata/pancakes.cook", "--scale", "2")
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(out, "# pancakes") || !strings.Contains(out, "250 g flour") {
+		t.Errorf("expected scaled markdown, got:\n%s", out)
+	}
+
+	_, errOut, code = runTest(t, "render", "--format", "pdf", "testdata/pancakes.cook")
+	if code != 2 || !strings.Contains(errOut, "unknown format 'pdf'") {
+		t.Errorf("expected unknown format error, got %d: %s", code, errOut)
+	}
+
+	// Files never get escape codes, even when run from a terminal.
+	name := filepath.Join(t.TempDir(), "pancakes.txt")
+	_, errOut, code = runTest(t, "render", "--format", "text", "-o", name, "testdata/pancakes.cook")
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.Contains(string(b), "flour") || strings.Contains(string(b), "\x1b[") {
+		t.Errorf("expected plain text, got:\n%q", b)
+	}
+
+	wrapped := map[string]string{
+		"40": "1. Crack the eggs into a blender, then\n   add the flour",
+		"0":  "1. Crack the eggs into a blender, then add the flour, milk and sea salt, and blitz until smooth.\n",
+	}
+	for width, step := range wrapped {
+		out, errOut, code = runTest(t, "render", "--format", "text", "--width", width, "testdata/pancakes.cook")
+		if code != 0 || !strings.Contains(out, step) {
+			t.Errorf("expected lines wrapped at %s, got %d: %s\n%s", width, code, errOut, out)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	aroma := filepath.Join(dir, "pancakes.aroma")
+	_, errOut, code := runTest(t, "convert", "testdata/pancakes.cook", "-o", aroma)
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+
+	b, err := os.ReadFile(aroma)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.Contains(string(b), `(ingredient "sea salt" {:quantity "1" :unit "pinch"})`) {
+		t.Errorf("expected aromalang, got:\n%s", b)
+	}
+
+	out, errOut, code := runTest(t, "convert", aroma)
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+	if !strings.Contains(out, "@sea salt{1%pinch}") {
+		t.Errorf("expected Cooklang, got:\n%s", out)
+	}
+}
+
+func TestParse(t *testing.T) {
+	out, _, code := runTest(t, "parse", "--ast", "testdata/pancakes.cook")
+	if code != 0 || !strings.Contains(out, `ingredient 3:11 "eggs" quantity="3" unit=""`) {
+		t.Errorf("expected the syntax tree, got %d:\n%s", code, out)
+	}
+
+	_, errOut, code := runTest(t, "parse", "testdata/broken.aroma")
+	if code != 1 || errOut != "testdata/broken.aroma:2:13: metadata values must be a string, got Numeral\n" {
+		t.Errorf("expected a positioned parse error, got %d: %q", code, errOut)
+	}
+
+	_, errOut, code = runTest(t, "parse", "testdata/broken.cook")
+	if code != 1 || errOut != "testdata/broken.cook:2:1: expected colon to separate metadata key and value\n" {
+		t.Errorf("expected a positioned parse error, got %d: %q", code, errOut)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	_, errOut, code := runTest(t, "bake")
+	if code != 2 || !strings.Contains(errOut, "unknown command 'bake'") {
+		t.Errorf("expected unknown command, got %d: %s", code, errOut)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	src, err := os.ReadFile("testdata/tea.cook")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	name := filepath.Join(t.TempDir(), "tea.cook")
+	if err := os.WriteFile(name, src, 0o644); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	out, _, code := runTest(t, "fmt", "-l", "-d", name)
+	if code != 0 || !strings.HasPrefix(out, name+"\n--- "+name+".orig\n+++ "+name+"\n@@ -1,9 +1,8 @@\n") ||
+		!strings.Contains(out, "\n->>   title :  Tea\n") || !strings.Contains(out, "\n+>> title: Tea\n") {
+		t.Errorf("expected the file to be listed with a diff, got %d:\n%s", code, out)
+	}
+
+	if _, _, code = runTest(t, "fmt", "-w", name); code != 0 {
+		t.Errorf("expected success, got %d", code)
+	}
+	formatted, err := os.ReadFile(name)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(string(formatted), ">> title: Tea\n>> servings: 2\n\nBoil @water{500%ml} in a #kettle{}.\n") {
+		t.Errorf("expected the file to be formatted, got:\n%s", formatted)
+	}
+
+	out, _, code = runTest(t, "fmt", "-l", filepath.Dir(name))
+	if code != 0 || out != "" {
+		t.Errorf("expected formatted files not to be listed, got %d: %s", code, out)
+	}
+}
+
+func TestShop(t *testing.T) {
+	out, errOut, code := runTest(t, "shop", "--aisles", "testdata/aisle.conf", "--pantry", "testdata/pantry.conf",
+		"--format", "csv", "testdata/pancakes.cook:2", "testdata/tea.cook:servings=4")
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+
+	expected := `aisle,ingredient,quantity,unit
+dairy,butter,,
+dairy,milk,500,ml
+baking,eggs,6,
+baking,flour,150,g
+,oil,,
+,tea,,
+,water,1000,ml
+`
+	if out != expected {
+		fmt.Println(out)
+		t.Errorf("unexpected shopping list")
+	}
+
+	out, _, code = runTest(t, "shop", "--format", "markdown", "testdata/tea.cook")
+	if code != 0 || out != "- [ ] milk\n- [ ] tea\n- [ ] 500 ml water\n" {
+		t.Errorf("expected a markdown checklist, got %d:\n%s", code, out)
+	}
+
+	_, errOut, code = runTest(t, "shop", "testdata/pancakes.cook:servings=4")
+	if code != 1 || !strings.Contains(errOut, "has no servings") {
+		t.Errorf("expected missing servings error, got %d: %s", code, errOut)
+	}
+}
+
+func TestRun(t *testing.T) {
+	stdin = strings.NewReader("t\n\n\np\nq\n")
+	defer func() { stdin = os.Stdin }()
+
+	out, errOut, code := runTest(t, "run", "testdata/tea.cook")
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+	for _, expected := range []string{
+		"Tea — step 1 of 2\n\n  • 500 ml water\n  • tea\n\nBoil water in a kettle. Add tea and wait 3 minutes.\n",
+		"  step 1       3:00\n",
+		"Tea — step 2 of 2\n\n  • milk\n\nServe with milk .\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
+
+func TestSessionTimers(t *testing.T) {
+	ast, err := parseFile("testdata/pancakes.cook")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newSession(ast, func() time.Time { return now })
+	s.key("right")
+	s.key("t")
+	s.key("t")
+	if len(s.timers) != 1 {
+		t.Errorf("expected the step's timer to be started once, got %d", len(s.timers))
+	}
+
+	now = now.Add(14*time.Minute + 30*time.Second)
+	buf := bytes.Buffer{}
+	s.update(&buf)
+	s.draw(&buf)
+	if buf.String()[0] == '\a' || !strings.Contains(buf.String(), "step 2       0:30") {
+		t.Errorf("expected 30 seconds left, got:\n%q", buf.String())
+	}
+
+	now = now.Add(time.Minute)
+	buf.Reset()
+	s.update(&buf)
+	s.update(&buf)
+	if buf.String() != "\a" {
+		t.Errorf("expected a single bell when the timer is done, got %q", buf.String())
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	out, errOut, code := runTest(t, "suggest", "--pantry", "testdata/pantry.conf", "-n", "1", "testdata")
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+	if out != " 40%  pancakes (pancakes.cook)\n      missing 3 eggs, 25 g flour, 250 ml milk, butter, oil\n" {
+		t.Errorf("expected the pancakes with what is missing, got:\n%s", out)
+	}
+	if !strings.Contains(errOut, "broken.aroma:2:13:") || !strings.Contains(errOut, "broken.cook:2:1:") {
+		t.Errorf("expected the broken recipes to be reported, got: %s", errOut)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	out, errOut, code := runTest(t, "substitute", "--db", "testdata/substitutions.conf", "--avoid", "eggs,milk", "testdata/pancakes.cook")
+	if code != 0 {
+		t.Errorf("expected success, got %d: %s", code, errOut)
+		t.FailNow()
+	}
+	if !strings.HasPrefix(out, "step 1: 3 eggs → 3 tbsp ground flaxseed + 9 tbsp water\n") ||
+		!strings.Contains(out, "step 1: 250 ml milk → 250 ml oat milk\n") {
+		t.Errorf("expected suggestions for the eggs and milk, got:\n%s", out)
+	}
+
+	out, errOut, code = runTest(t, "substitute", "--db", "testdata/substitutions.conf", "--avoid", "butter,oil", "--rewrite", "testdata/pancakes.cook")
+	if code != 1 || !strings.Contains(errOut, "no substitute for oil") {
+		t.Errorf("expected oil to have no substitute, got %d: %s", code, errOut)
+	}
+	if !strings.Contains(out, "Melt the @margarine (or a drizzle of @oil if") || !strings.Contains(out, "-- instead of butter\n") {
+		t.Errorf("expected the butter to be replaced, got:\n%s", out)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(t.TempDir(), "index.json")
+	write := func(name, src string, modTime time.Time) {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+	}
+
+	write("tea.cook", "Boil @water{1%l}.\n", time.Now())
+	out, errOut, code := runTest(t, "search", "-C", dir, "--index", index, "water")
+	if code != 0 || !strings.Contains(out, "tea.cook") {
+		t.Errorf("expected tea to be found, got %d: %s%s", code, out, errOut)
+	}
+
+	// A recipe copied in with an old modification time is still new to
+	// the index.
+	write("omelette.cook", "Whisk @eggs{3}.\n", time.Now().Add(-24*time.Hour))
+	out, errOut, code = runTest(t, "search", "-C", dir, "--index", index, "eggs")
+	if code != 0 || !strings.Contains(out, "omelette.cook") {
+		t.Errorf("expected the old recipe to be indexed, got %d: %s%s", code, out, errOut)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(command{
+		Name:    "parse",
+		Summary: "check recipes for errors, or dump their syntax tree",
+		Run:     runParse,
+	})
+}
+
+func runParse(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("parse", "file...", stderr)
+	dump := fs.Bool("ast", false, "print the syntax tree of every recipe")
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(files) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	code := 0
+	for _, name := range files {
+		ast, err := parseFile(name)
+		if err != nil {
+			report(stderr, err)
+			code = 1
+			continue
+		}
+		if *dump {
+			dumpAST(stdout, ast)
+		}
+	}
+	return code
+}
+
+// dumpAST writes an indented tree of the recipe's components, with the
+// position and fields of each.
+func dumpAST(w io.Writer, ast *aromalang.AST) {
+	fmt.Fprintf(w, "recipe %s\n", ast.Filename)
+	for _, md := range ast.Recipe.Metadata {
+		dumpComponent(w, 1, md)
+	}
+	for _, step := range ast.Recipe.Steps {
+		fmt.Fprintf(w, "  step %s\n", position(step.Pos.Line, step.Pos.Column))
+		for _, c := range step.Components {
+			dumpComponent(w, 2, c)
+		}
+	}
+}
+
+func dumpComponent(w io.Writer, depth int, c aromalang.Component) {
+	var kind string
+	var fields []string
+	switch c := c.(type) {
+	case aromalang.Metadata:
+		kind, fields = "metadata", []string{strconv.Quote(c.Key), strconv.Quote(c.Value)}
+	case aromalang.Instruction:
+		kind, fields = "instruction", []string{strconv.Quote(c.Instruction)}
+	case aromalang.Ingredient:
+		kind, fields = "ingredient", []string{strconv.Quote(c.Name), "quantity=" + strconv.Quote(c.Quantity), "unit=" + strconv.Quote(c.Unit)}
+	case aromalang.Cookware:
+		kind, fields = "cookware", []string{strconv.Quote(c.Name)}
+	case aromalang.Timer:
+		kind, fields = "timer", []string{strconv.Quote(c.Name), "magnitude=" + strconv.Quote(c.Magnitude), "unit=" + strconv.Quote(c.Unit)}
+	case aromalang.Comment:
+		kind, fields = "comment", []string{strconv.Quote(c.Comment)}
+	default:
+		kind = fmt.Sprintf("%T", c)
+	}
+
+	pos := c.Position()
+	fmt.Fprintf(w, "%s%s %s %s\n", strings.Repeat("  ", depth), kind, position(pos.Line, pos.Column), strings.Join(fields, " "))
+}
+
+func position(line, column int) string {
+	return strconv.Itoa(line) + ":" + strconv.Itoa(column)
+}
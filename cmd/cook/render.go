@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/renderer"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	register(command{
+		Name:    "render",
+		Summary: "render a recipe as " + strings.Join(renderer.Formats(), ", "),
+		Run:     runRender,
+	})
+}
+
+// optionFlags are the flags shared by the commands that render
+// recipes.
+type optionFlags struct {
+	scale    *float64
+	servings *float64
+	units    *string
+	locale   *string
+}
+
+func addOptionFlags(fs *flag.FlagSet) optionFlags {
+	return optionFlags{
+		scale:    fs.Float64("scale", 1, "multiply every quantity by `factor`"),
+		servings: fs.Float64("servings", 0, "scale the recipe to `n` servings, if it has servings metadata"),
+		units:    fs.String("units", "", "convert quantities to the metric or imperial `system`"),
+		locale:   fs.String("locale", "", "`locale` used for labels and numbers, such as sv-SE"),
+	}
+}
+
+func (f optionFlags) options() (renderer.Options, error) {
+	units, err := conversion.ParseSystem(*f.units)
+	if err != nil {
+		return renderer.Options{}, err
+	}
+	return renderer.Options{Scale: *f.scale, Units: units, Locale: *f.locale}, nil
+}
+
+func runRender(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("render", "file", stderr)
+	format := fs.String("format", "html", "output `format`: "+strings.Join(renderer.Formats(), ", "))
+	output := fs.String("o", "", "write to `file` instead of stdout")
+	width := fs.Int("width", renderer.DefaultWidth, "wrap text at `columns`, or not at all if 0")
+	flags := addOptionFlags(fs)
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	opts, err := flags.options()
+	if err != nil {
+		report(stderr, err)
+		return 2
+	}
+
+	ast, err := parseFile(files[0])
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	if *flags.servings > 0 {
+		servings, ok := ast.Servings()
+		if !ok {
+			report(stderr, fmt.Errorf("%s has no servings to scale from", files[0]))
+			return 1
+		}
+		opts.Scale = *flags.servings / servings
+	}
+
+	r, err := renderer.New(*format, ast)
+	if err != nil {
+		report(stderr, err)
+		return 2
+	}
+
+	if t, ok := r.(renderer.Text); ok {
+		t.Color = *output == "" && colorful(stdout)
+		t.Width = *width
+		r = t
+	}
+
+	err = writeOutput(*output, stdout, func(w io.Writer) error {
+		return r.RenderTo(w, opts)
+	})
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// colorful returns true if w is a terminal that ANSI escape codes can
+// be written to, unless they are turned off with NO_COLOR.
+func colorful(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && renderer.IsTerminal(f) && os.Getenv("NO_COLOR") == ""
+}
+
+// writeOutput calls write with the file called name, or with stdout
+// if name is empty.
+func writeOutput(name string, stdout io.Writer, write func(w io.Writer) error) error {
+	if name == "" {
+		return write(stdout)
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
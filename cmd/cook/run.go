@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(command{
+		Name:    "run",
+		Summary: "cook a recipe step by step in the terminal, with timers",
+		Run:     runRun,
+	})
+}
+
+// stdin is read for keypresses by 'cook run'.
+var stdin io.Reader = os.Stdin
+
+func runRun(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("run", "file", stderr)
+	flags := addOptionFlags(fs)
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(files) != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	opts, err := flags.options()
+	if err != nil {
+		report(stderr, err)
+		return 2
+	}
+	ast, err := parseFile(files[0])
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	if *flags.servings > 0 {
+		servings, ok := ast.Servings()
+		if !ok {
+			report(stderr, fmt.Errorf("%s has no servings to scale from", files[0]))
+			return 1
+		}
+		opts.Scale = *flags.servings / servings
+	}
+
+	s := newSession(opts.Apply(ast), time.Now)
+	if len(s.steps) == 0 {
+		report(stderr, fmt.Errorf("%s has no steps", files[0]))
+		return 1
+	}
+
+	// Without raw mode every key has to be followed by enter.
+	s.lineMode = true
+	if stdin == os.Stdin {
+		if restore, err := rawMode(); err == nil {
+			s.lineMode = false
+			defer restore()
+		}
+	}
+
+	keys := make(chan string)
+	go readKeys(stdin, keys)
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	for {
+		s.update(w)
+		s.draw(w)
+		w.Flush()
+
+		select {
+		case k, ok := <-keys:
+			if !ok || !s.key(k) {
+				fmt.Fprint(w, "\r\n")
+				return 0
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// session is the state of cooking a recipe in the terminal.
+type session struct {
+	title string
+	steps []aromalang.Step
+	// step is the index of the current step.
+	step     int
+	timers   []*countdown
+	started  map[[2]int]bool
+	now      func() time.Time
+	lineMode bool
+}
+
+// countdown is a running timer.
+type countdown struct {
+	label string
+	end   time.Time
+	done  bool
+}
+
+func newSession(ast *aromalang.AST, now func() time.Time) *session {
+	s := &session{
+		title:   ast.Title(),
+		started: map[[2]int]bool{},
+		now:     now,
+	}
+	for _, step := range ast.Recipe.Steps {
+		if step.Text() != "" {
+			s.steps = append(s.steps, step)
+		}
+	}
+	return s
+}
+
+// key handles a keypress and returns false if the session should end.
+func (s *session) key(k string) bool {
+	switch k {
+	case "q", "ctrl-c", "esc":
+		return false
+	case "n", " ", "enter", "right", "l", "j", "down":
+		if s.step < len(s.steps)-1 {
+			s.step++
+		}
+	case "p", "left", "h", "k", "up", "backspace":
+		if s.step > 0 {
+			s.step--
+		}
+	case "t":
+		s.startTimers()
+	}
+	return true
+}
+
+// startTimers starts the timers of the current step that aren't
+// already running. Timers in other steps keep running.
+func (s *session) startTimers() {
+	for i, t := range s.steps[s.step].Timers() {
+		d, err := t.Duration()
+		if err != nil || d.IsZero() || s.started[[2]int{s.step, i}] {
+			continue
+		}
+		s.started[[2]int{s.step, i}] = true
+
+		label := t.Name
+		if label == "" {
+			label = fmt.Sprintf("step %d", s.step+1)
+		}
+		s.timers = append(s.timers, &countdown{
+			label: label,
+			end:   d.AddTime(s.now()),
+		})
+	}
+}
+
+// update marks timers that have run out as done and rings the bell for
+// each of them.
+func (s *session) update(w io.Writer) {
+	now := s.now()
+	for _, c := range s.timers {
+		if !c.done && !now.Before(c.end) {
+			c.done = true
+			fmt.Fprint(w, "\a")
+		}
+	}
+}
+
+// stepText returns the text of a step on a single line. The lines of
+// a step in the source are joined with a space, since Cooklang keeps
+// them as separate instructions.
+func stepText(step aromalang.Step) string {
+	b := strings.Builder{}
+	line := 0
+	for _, c := range step.Components {
+		var text string
+		switch c := c.(type) {
+		case aromalang.Instruction:
+			text = c.Instruction
+		case aromalang.Ingredient:
+			text = c.Name
+		case aromalang.Cookware:
+			text = c.Name
+		case aromalang.Timer:
+			text = c.Magnitude + " " + c.Unit
+		default:
+			continue
+		}
+		if pos := c.Position(); pos.Line > line {
+			if line != 0 {
+				b.WriteString(" ")
+			}
+			line = pos.Line
+		}
+		b.WriteString(text)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func (s *session) draw(w io.Writer) {
+	b := strings.Builder{}
+	step := s.steps[s.step]
+
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "%s — step %d of %d\n\n", s.title, s.step+1, len(s.steps))
+
+	if ings := step.Ingredients(); len(ings) != 0 {
+		for _, ing := range ings {
+			fmt.Fprintf(&b, "  • %s\n", strings.Join(strings.Fields(ing.Quantity+" "+ing.Unit+" "+ing.Name), " "))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(stepText(step) + "\n")
+
+	if len(s.timers) != 0 {
+		b.WriteString("\nTimers\n")
+		now := s.now()
+		for _, c := range s.timers {
+			if c.done {
+				fmt.Fprintf(&b, "  %-12s done\n", c.label)
+			} else {
+				fmt.Fprintf(&b, "  %-12s %s\n", c.label, formatRemaining(c.end.Sub(now)))
+			}
+		}
+	}
+
+	help := "\n← previous  → next"
+	if len(step.Timers()) != 0 {
+		help += "  t start timers"
+	}
+	help += "  q quit"
+	if s.lineMode {
+		help += "  (press enter after each key)"
+	}
+	b.WriteString(help)
+
+	out := b.String()
+	if !s.lineMode {
+		// The terminal doesn't return to the start of the line by
+		// itself in raw mode.
+		out = strings.ReplaceAll(out, "\n", "\r\n")
+	}
+	io.WriteString(w, out)
+}
+
+// formatRemaining formats a duration as m:ss or h:mm:ss, rounded up to
+// whole seconds.
+func formatRemaining(d time.Duration) string {
+	secs := int64((d + time.Second - 1) / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// readKeys sends the keys read from r to keys, with arrow keys and
+// control characters given names, until r is closed.
+func readKeys(r io.Reader, keys chan<- string) {
+	defer close(keys)
+	br := bufio.NewReader(r)
+	var prev byte
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return
+		}
+		last := prev
+		prev = c
+
+		switch c {
+		case 3:
+			keys <- "ctrl-c"
+		case '\r':
+			keys <- "enter"
+		case '\n':
+			// Ends every key in line mode, on its own it is enter.
+			if last == 0 || last == '\n' {
+				keys <- "enter"
+			}
+		case 127, 8:
+			keys <- "backspace"
+		case 27:
+			if br.Buffered() == 0 {
+				keys <- "esc"
+				continue
+			}
+			seq := make([]byte, 2)
+			if _, err := io.ReadFull(br, seq); err != nil {
+				return
+			}
+			if seq[0] == '[' || seq[0] == 'O' {
+				switch seq[1] {
+				case 'A':
+					keys <- "up"
+				case 'B':
+					keys <- "down"
+				case 'C':
+					keys <- "right"
+				case 'D':
+					keys <- "left"
+				}
+			}
+		default:
+			keys <- strings.ToLower(string(c))
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/load"
+	"github.com/dememorized/cook/library"
+	"github.com/dememorized/cook/search"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func init() {
+	register(command{
+		Name:    "search",
+		Summary: "search a directory of recipes by words, ingredients and tags",
+		Run:     runSearch,
+	})
+}
+
+func runSearch(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("search", "query...", stderr)
+	dir := fs.String("C", ".", "search the recipes in `dir`")
+	indexFile := fs.String("index", "", "keep the index in `file` and only re-index recipes that changed since they were indexed")
+	synonymsFile := fs.String("synonyms", "", "match the ingredient names listed as synonyms in `file`")
+	words, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(words) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	synonyms, err := loadConfig(*synonymsFile, "", canonical.LoadSynonyms)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	fsys := os.DirFS(*dir)
+	var idx *search.Index
+	if *indexFile != "" {
+		idx, err = updateIndex(fsys, *indexFile, synonyms, stderr)
+	} else {
+		var lib *library.Library
+		lib, err = library.Load(fsys, 0)
+		if err == nil {
+			for _, e := range lib.Errors {
+				report(stderr, e.Err)
+			}
+			idx = search.FromLibrary(lib, synonyms)
+		}
+	}
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	results, err := idx.Search(strings.Join(words, " "))
+	if err != nil {
+		report(stderr, err)
+		return 2
+	}
+	if len(results) == 0 {
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\n", r.Title, r.Path)
+	}
+	tw.Flush()
+	return 0
+}
+
+// updateIndex reads the index in name, re-indexes the recipes in fsys
+// whose size or modification time changed since they were indexed and
+// writes it back. The index is
+// built from scratch if it doesn't exist or can't be read.
+func updateIndex(fsys fs.FS, name string, synonyms *canonical.Dictionary, stderr io.Writer) (*search.Index, error) {
+	var idx *search.Index
+	if f, err := os.Open(name); err == nil {
+		idx, _ = search.Read(f, synonyms)
+		f.Close()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if idx == nil {
+		idx = search.New(synonyms)
+	}
+
+	exists := map[string]bool{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != "." && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if d.IsDir() || !load.Supported(p) {
+			return nil
+		}
+		exists[p] = true
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !idx.Changed(p, info) {
+			return nil
+		}
+		if err := idx.Update(fsys, p); err != nil {
+			report(stderr, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range idx.Paths() {
+		if !exists[p] {
+			idx.Remove(p)
+		}
+	}
+
+	err = writeOutput(name, nil, func(w io.Writer) error {
+		_, err := idx.WriteTo(w)
+		return err
+	})
+	return idx, err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/renderer"
+	"github.com/dememorized/cook/site"
+	"io"
+	"net/http"
+	"os"
+)
+
+func init() {
+	register(command{
+		Name:    "serve",
+		Summary: "browse a directory of recipes in a web browser",
+		Run:     runServe,
+	})
+}
+
+func runServe(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("serve", "[dir]", stderr)
+	addr := fs.String("addr", "localhost:8080", "listen on `address`, use :8080 to allow other devices")
+	title := fs.String("title", "", "`title` of the site")
+	units := fs.String("units", "", "convert quantities to the metric or imperial `system` by default")
+	locale := fs.String("locale", "", "`locale` used for labels and numbers, such as sv-SE")
+	dirs, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(dirs) > 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := "."
+	if len(dirs) == 1 {
+		dir = dirs[0]
+	}
+
+	system, err := conversion.ParseSystem(*units)
+	if err != nil {
+		report(stderr, err)
+		return 2
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		report(stderr, fmt.Errorf("%s is not a directory", dir))
+		return 1
+	}
+
+	s := &site.Site{
+		Source:  os.DirFS(dir),
+		Title:   *title,
+		Options: renderer.Options{Units: system, Locale: *locale},
+	}
+
+	fmt.Fprintf(stdout, "serving %s on http://%s/\n", dir, *addr)
+	if err := http.ListenAndServe(*addr, s.Handler()); err != nil {
+		report(stderr, err)
+		return 1
+	}
+	return 0
+}
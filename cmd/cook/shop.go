@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/ingredients"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/mealplan"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func init() {
+	register(command{
+		Name:    "shop",
+		Summary: "make a shopping list from recipes and meal plans",
+		Run:     runShop,
+	})
+}
+
+const (
+	defaultAisles = "config/aisle.conf"
+	defaultPantry = "config/pantry.conf"
+)
+
+func runShop(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("shop", "recipe[:scale|:servings=n]... plan...", stderr)
+	format := fs.String("format", "text", "output `format`: text, markdown, csv or json")
+	aislesFile := fs.String("aisles", "", "group ingredients by the aisles in `file` (default "+defaultAisles+" if it exists)")
+	pantryFile := fs.String("pantry", "", "leave out the ingredients in `file` (default "+defaultPantry+" if it exists)")
+	synonymsFile := fs.String("synonyms", "", "combine the ingredient names listed as synonyms in `file`")
+	units := fs.String("units", "", "convert quantities to the metric or imperial `system`")
+	output := fs.String("o", "", "write to `file` instead of stdout")
+	sources, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(sources) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	write, ok := shoppingFormats[*format]
+	if !ok {
+		report(stderr, fmt.Errorf("unknown format '%s', expected text, markdown, csv or json", *format))
+		return 2
+	}
+	system, err := conversion.ParseSystem(*units)
+	if err != nil {
+		report(stderr, err)
+		return 2
+	}
+
+	aisles, err := loadConfig(*aislesFile, defaultAisles, ingredients.LoadAisles)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	pantry, err := loadConfig(*pantryFile, defaultPantry, ingredients.LoadPantry)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	synonyms, err := loadConfig(*synonymsFile, "", canonical.LoadSynonyms)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	list := &ingredients.List{Key: aisles.Synonyms().Key}
+	if synonyms != nil {
+		list.Key = func(name string) string {
+			return synonyms.Key(aisles.Synonyms().Key(name))
+		}
+	}
+	for _, src := range sources {
+		if err := addToShoppingList(list, src); err != nil {
+			report(stderr, err)
+			return 1
+		}
+	}
+
+	// The items share their amounts with the list, so they are
+	// converted into new slices.
+	sections := list.Subtract(pantry).ByAisle(aisles)
+	for _, s := range sections {
+		for i := range s.Items {
+			amounts := make([]conversion.Quantity, 0, len(s.Items[i].Amounts))
+			for _, a := range s.Items[i].Amounts {
+				amounts = append(amounts, a.To(system))
+			}
+			s.Items[i].Amounts = amounts
+		}
+	}
+
+	err = writeOutput(*output, stdout, func(w io.Writer) error {
+		return write(w, sections)
+	})
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig reads the file called name with load. If name is empty
+// the file called fallback is read instead if it exists, and if it
+// doesn't the zero value is returned.
+func loadConfig[T any](name, fallback string, load func(io.Reader) (T, error)) (T, error) {
+	var zero T
+	if name == "" {
+		if _, err := os.Stat(fallback); fallback == "" || err != nil {
+			return zero, nil
+		}
+		name = fallback
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return zero, err
+	}
+	defer f.Close()
+
+	res, err := load(f)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", name, err)
+	}
+	return res, nil
+}
+
+// addToShoppingList adds the ingredients of a recipe or meal plan. A
+// recipe can be followed by a colon and either a factor to scale it by,
+// as in pancakes.cook:2, or the number of servings to make, as in
+// pancakes.cook:servings=6.
+func addToShoppingList(list *ingredients.List, src string) error {
+	if strings.EqualFold(filepath.Ext(src), ".plan") {
+		plan, err := mealplan.LoadFile(os.DirFS(filepath.Dir(src)), filepath.Base(src))
+		if err != nil {
+			return err
+		}
+		for _, m := range plan.Meals() {
+			list.AddRecipe(m.Recipe, m.Scale())
+		}
+		return nil
+	}
+
+	name, scale, servings, err := splitScale(src)
+	if err != nil {
+		return err
+	}
+	ast, err := parseFile(name)
+	if err != nil {
+		return err
+	}
+	if servings != 0 {
+		s, ok := ast.Servings()
+		if !ok {
+			return fmt.Errorf("%s has no servings to scale from", name)
+		}
+		scale = servings / s
+	}
+
+	list.AddRecipe(ast, scale)
+	return nil
+}
+
+func splitScale(src string) (string, float64, float64, error) {
+	i := strings.LastIndexByte(src, ':')
+	if i == -1 || strings.ContainsAny(src[i:], `/\`) {
+		return src, 1, 0, nil
+	}
+	name, suffix := src[:i], src[i+1:]
+
+	if strings.HasPrefix(suffix, "servings=") {
+		n := strings.TrimPrefix(suffix, "servings=")
+		servings, err := strconv.ParseFloat(n, 64)
+		if err != nil || servings <= 0 {
+			return "", 0, 0, fmt.Errorf("%s: invalid number of servings '%s'", name, n)
+		}
+		return name, 1, servings, nil
+	}
+
+	scale, err := conversion.Numeral(suffix).Float()
+	if err != nil || scale <= 0 {
+		return "", 0, 0, fmt.Errorf("%s: invalid scale '%s'", name, suffix)
+	}
+	return name, scale, 0, nil
+}
+
+var shoppingFormats = map[string]func(io.Writer, []ingredients.Section) error{
+	"text":     writeShoppingText,
+	"markdown": writeShoppingMarkdown,
+	"csv":      writeShoppingCSV,
+	"json":     writeShoppingJSON,
+}
+
+func aisleName(s ingredients.Section) string {
+	if s.Aisle == "" {
+		return "other"
+	}
+	return s.Aisle
+}
+
+func writeShoppingText(w io.Writer, sections []ingredients.Section) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for i, s := range sections {
+		if len(sections) > 1 {
+			if i != 0 {
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintln(tw, strings.ToUpper(aisleName(s)))
+		}
+		for _, item := range s.Items {
+			fmt.Fprintf(tw, "  %s\t%s\n", item.Quantity(), item.Name)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeShoppingMarkdown(w io.Writer, sections []ingredients.Section) error {
+	b := strings.Builder{}
+	for i, s := range sections {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		if len(sections) > 1 {
+			b.WriteString("## " + aisleName(s) + "\n\n")
+		}
+		for _, item := range s.Items {
+			b.WriteString("- [ ] " + strings.TrimSpace(item.Quantity()+" "+item.Name) + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeShoppingCSV writes a row for every amount of every item, and a
+// row without quantity for items that only have notes or are
+// unmeasured.
+func writeShoppingCSV(w io.Writer, sections []ingredients.Section) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"aisle", "ingredient", "quantity", "unit"}); err != nil {
+		return err
+	}
+	for _, s := range sections {
+		for _, item := range s.Items {
+			for _, a := range item.Amounts {
+				if err := cw.Write([]string{s.Aisle, item.Name, conversion.FormatFloat(a.Value), a.Unit.Symbol}); err != nil {
+					return err
+				}
+			}
+			for _, n := range item.Notes {
+				if err := cw.Write([]string{s.Aisle, item.Name, n, ""}); err != nil {
+					return err
+				}
+			}
+			if len(item.Amounts) == 0 && len(item.Notes) == 0 {
+				if err := cw.Write([]string{s.Aisle, item.Name, "", ""}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type shoppingJSONSection struct {
+	Aisle string             `json:"aisle"`
+	Items []shoppingJSONItem `json:"items"`
+}
+
+type shoppingJSONItem struct {
+	Name    string               `json:"name"`
+	Amounts []shoppingJSONAmount `json:"amounts"`
+	Notes   []string             `json:"notes,omitempty"`
+}
+
+type shoppingJSONAmount struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+func writeShoppingJSON(w io.Writer, sections []ingredients.Section) error {
+	res := make([]shoppingJSONSection, 0, len(sections))
+	for _, s := range sections {
+		section := shoppingJSONSection{Aisle: s.Aisle, Items: []shoppingJSONItem{}}
+		for _, item := range s.Items {
+			i := shoppingJSONItem{Name: item.Name, Amounts: []shoppingJSONAmount{}, Notes: item.Notes}
+			for _, a := range item.Amounts {
+				q, _ := strconv.ParseFloat(conversion.FormatFloat(a.Value), 64)
+				i.Amounts = append(i.Amounts, shoppingJSONAmount{Quantity: q, Unit: a.Unit.Symbol})
+			}
+			section.Items = append(section.Items, i)
+		}
+		res = append(res, section)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/cooklang"
+	"github.com/dememorized/cook/internal/load"
+	"github.com/dememorized/cook/substitution"
+	"io"
+	"path"
+	"strings"
+)
+
+const defaultSubstitutions = "config/substitutions.conf"
+
+func init() {
+	register(command{
+		Name:    "substitute",
+		Summary: "suggest replacements for ingredients, or rewrite a recipe to use them",
+		Run:     runSubstitute,
+	})
+}
+
+func runSubstitute(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("substitute", "--avoid ingredient,... file", stderr)
+	dbFile := fs.String("db", "", "read the substitution rules from `file` (default "+defaultSubstitutions+")")
+	avoid := fs.String("avoid", "", "comma separated `list` of the ingredients to replace")
+	synonymsFile := fs.String("synonyms", "", "match the ingredient names listed as synonyms in `file`")
+	rewrite := fs.Bool("rewrite", false, "print the recipe with the first substitute for each ingredient")
+	output := fs.String("o", "", "write to `file` instead of stdout")
+	files, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	var avoided []string
+	for _, a := range strings.Split(*avoid, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			avoided = append(avoided, a)
+		}
+	}
+	if len(files) != 1 || len(avoided) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if *dbFile == "" {
+		*dbFile = defaultSubstitutions
+	}
+	db, err := loadConfig(*dbFile, "", substitution.Load)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	synonyms, err := loadConfig(*synonymsFile, "", canonical.LoadSynonyms)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	db.UseDictionary(synonyms)
+
+	ast, err := parseFile(files[0])
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	if !*rewrite {
+		err = writeOutput(*output, stdout, func(w io.Writer) error {
+			for _, s := range db.Suggest(ast, avoided) {
+				if _, err := fmt.Fprintf(w, "step %d: %s\n", s.Step+1, s); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			report(stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	res, _, rewriteErr := db.Rewrite(ast, avoided)
+	// The rewritten recipe is written in the syntax of the output file,
+	// or of the source when writing to stdout.
+	name := files[0]
+	if *output != "" {
+		name = *output
+	}
+	format := aromalang.Format
+	if strings.EqualFold(path.Ext(name), load.ExtCooklang) {
+		// The lines of a Cooklang source are kept, with the
+		// substitutions noted at the end of them.
+		printer := cooklang.Printer{KeepLines: strings.EqualFold(path.Ext(files[0]), load.ExtCooklang)}
+		format = printer.Format
+	}
+	err = writeOutput(*output, stdout, func(w io.Writer) error {
+		_, err := io.WriteString(w, format(res))
+		return err
+	})
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	if rewriteErr != nil {
+		report(stderr, fmt.Errorf("%s: %w", files[0], rewriteErr))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/ingredients"
+	"github.com/dememorized/cook/library"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	register(command{
+		Name:    "suggest",
+		Summary: "list the recipes that can be made with what is in the pantry",
+		Run:     runSuggest,
+	})
+}
+
+func runSuggest(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("suggest", "[dir]", stderr)
+	pantryFile := fs.String("pantry", "", "the ingredients on hand are in `file` (default "+defaultPantry+")")
+	synonymsFile := fs.String("synonyms", "", "match the ingredient names listed as synonyms in `file`")
+	limit := fs.Int("n", 10, "list at most `n` recipes, 0 lists every recipe")
+	dirs, err := parseFlags(fs, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(dirs) > 1 {
+		fs.Usage()
+		return 2
+	}
+	dir := "."
+	if len(dirs) == 1 {
+		dir = dirs[0]
+	}
+
+	if *pantryFile == "" {
+		*pantryFile = defaultPantry
+	}
+	pantry, err := loadConfig(*pantryFile, "", ingredients.LoadPantry)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	synonyms, err := loadConfig(*synonymsFile, "", canonical.LoadSynonyms)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+
+	lib, err := library.Load(os.DirFS(dir), 0)
+	if err != nil {
+		report(stderr, err)
+		return 1
+	}
+	for _, e := range lib.Errors {
+		report(stderr, e)
+	}
+
+	matches := lib.MatchPantry(pantry, synonyms)
+	if *limit > 0 && len(matches) > *limit {
+		matches = matches[:*limit]
+	}
+	for _, m := range matches {
+		fmt.Fprintf(stdout, "%3.0f%%  %s (%s)\n", m.Score*100, m.Recipe.AST.Title(), m.Recipe.Path)
+		if len(m.Missing) == 0 {
+			continue
+		}
+		missing := make([]string, 0, len(m.Missing))
+		for _, item := range m.Missing {
+			missing = append(missing, strings.TrimSpace(item.Quantity()+" "+item.Name))
+		}
+		fmt.Fprintf(stdout, "      missing %s\n", strings.Join(missing, ", "))
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// rawMode puts the terminal on stdin in raw mode, so that keys are read
+// as they are pressed without being echoed, and returns a function that
+// restores it. It uses stty, and fails if stdin isn't a terminal or
+// stty isn't available.
+func rawMode() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+
+	// Hide the cursor while cooking.
+	os.Stdout.WriteString("\x1b[?25l")
+	return func() {
+		os.Stdout.WriteString("\x1b[?25h")
+		stty(strings.TrimSpace(state))
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
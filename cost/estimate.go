@@ -0,0 +1,108 @@
+package cost
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"math"
+)
+
+// Item is the cost of a single ingredient in a recipe.
+type Item struct {
+	Ingredient aromalang.Ingredient
+	Price      Price
+	// Quantity is the amount of the ingredient used, in the unit of
+	// the package it's sold in.
+	Quantity conversion.Quantity
+	// Cost is the cost of the amount of the ingredient that is used.
+	Cost float64
+	// Packages is the number of whole packages that has to be bought.
+	Packages int
+}
+
+// Unpriced is an ingredient that couldn't be priced, together with the
+// reason why.
+type Unpriced struct {
+	Ingredient aromalang.Ingredient
+	Reason     string
+}
+
+// Estimate is the cost of a recipe.
+type Estimate struct {
+	Items    []Item
+	Unpriced []Unpriced
+	// Total is the sum of the cost of every priced ingredient.
+	Total float64
+	// Shopping is the cost of buying every priced ingredient in whole
+	// packages. Ingredients used in several steps are bought together.
+	Shopping float64
+}
+
+// Estimate calculates the cost of making the recipe scale times.
+func (l *PriceList) Estimate(ast *aromalang.AST, scale float64) Estimate {
+	est := Estimate{}
+	used := map[string]float64{}
+	var order []Price
+
+	for _, step := range ast.Recipe.Steps {
+		for _, ing := range step.Ingredients() {
+			item, reason := l.estimateIngredient(ing, scale)
+			if reason != "" {
+				est.Unpriced = append(est.Unpriced, Unpriced{
+					Ingredient: ing,
+					Reason:     reason,
+				})
+				continue
+			}
+
+			est.Items = append(est.Items, item)
+			est.Total += item.Cost
+
+			key := l.names.Key(item.Price.Name)
+			if _, ok := used[key]; !ok {
+				order = append(order, item.Price)
+			}
+			used[key] += item.Quantity.Value
+		}
+	}
+
+	for _, p := range order {
+		amount := used[l.names.Key(p.Name)]
+		est.Shopping += float64(packages(amount, p.Size.Value)) * p.Price
+	}
+
+	return est
+}
+
+func (l *PriceList) estimateIngredient(ing aromalang.Ingredient, scale float64) (Item, string) {
+	p, ok := l.Lookup(ing.Name)
+	if !ok {
+		return Item{}, "not in price list"
+	}
+	if ing.Quantity == "" {
+		return Item{}, "no quantity"
+	}
+
+	q, err := conversion.ParseQuantity(ing.Quantity, ing.Unit)
+	if err != nil {
+		return Item{}, err.Error()
+	}
+
+	q, err = q.Scale(scale).In(p.Size.Unit)
+	if err != nil {
+		return Item{}, err.Error()
+	}
+
+	return Item{
+		Ingredient: ing,
+		Price:      p,
+		Quantity:   q,
+		Cost:       q.Value / p.Size.Value * p.Price,
+		Packages:   packages(q.Value, p.Size.Value),
+	}, ""
+}
+
+func packages(amount, size float64) int {
+	// Round away floating point noise before rounding up, so that
+	// 3 × 1/3 of a package doesn't become two packages.
+	return int(math.Ceil(math.Round(amount/size*1e6) / 1e6))
+}
@@ -0,0 +1,48 @@
+package cost
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"math"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/pancakes.aroma
+var pancakes string
+
+//go:embed testdata/prices.csv
+var prices []byte
+
+func TestEstimate(t *testing.T) {
+	list, err := LoadCSV(bytes.NewReader(prices))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(pancakes))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	est := list.Estimate(ast, 2)
+	for _, item := range est.Items {
+		fmt.Printf("%s: %s, %.2f\n", item.Ingredient.Name, item.Quantity, item.Cost)
+	}
+
+	// 6 eggs (18), 250 g flour (2.5) and 500 ml milk (6).
+	if math.Abs(est.Total-26.5) > 0.001 {
+		t.Errorf("expected total 26.5, got %f", est.Total)
+	}
+	// Half a dozen eggs, a bag of flour and a carton of milk.
+	if math.Abs(est.Shopping-68) > 0.001 {
+		t.Errorf("expected shopping cost 68, got %f", est.Shopping)
+	}
+	if len(est.Unpriced) != 3 {
+		t.Errorf("expected 3 unpriced ingredients, got %v", est.Unpriced)
+	}
+}
@@ -0,0 +1,119 @@
+package cost
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/conversion"
+	"io"
+	"strings"
+)
+
+// Price is the price of a package of an ingredient as it is sold.
+type Price struct {
+	Name  string
+	Size  conversion.Quantity
+	Price float64
+}
+
+// PriceList is a list of ingredient prices keyed by ingredient name.
+type PriceList struct {
+	prices []Price
+	index  map[string]int
+	names  *canonical.Dictionary
+}
+
+func NewPriceList(prices ...Price) *PriceList {
+	l := &PriceList{index: map[string]int{}}
+	for _, p := range prices {
+		l.Add(p)
+	}
+	return l
+}
+
+// Add inserts or replaces a price in the list.
+func (l *PriceList) Add(p Price) {
+	k := l.names.Key(p.Name)
+	if i, ok := l.index[k]; ok {
+		l.prices[i] = p
+		return
+	}
+	l.index[k] = len(l.prices)
+	l.prices = append(l.prices, p)
+}
+
+// UseDictionary matches ingredient names using the synonyms in d.
+func (l *PriceList) UseDictionary(d *canonical.Dictionary) {
+	prices := l.prices
+	l.prices = nil
+	l.index = map[string]int{}
+	l.names = d
+	for _, p := range prices {
+		l.Add(p)
+	}
+}
+
+// Lookup finds the price for an ingredient name.
+func (l *PriceList) Lookup(name string) (Price, bool) {
+	i, ok := l.index[l.names.Key(name)]
+	if !ok {
+		return Price{}, false
+	}
+	return l.prices[i], true
+}
+
+func (l *PriceList) Len() int {
+	return len(l.prices)
+}
+
+// LoadCSV reads a price list from CSV with the columns ingredient,
+// size, unit and price, in that order. The first row is a header and
+// is skipped. An empty unit means that the ingredient is sold by the
+// piece, so "eggs,12,,30" is a dozen eggs for 30.
+func LoadCSV(r io.Reader) (*PriceList, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = 4
+
+	if _, err := cr.Read(); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("price list is empty")
+		}
+		return nil, err
+	}
+
+	l := NewPriceList()
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		size, err := conversion.ParseQuantity(row[1], row[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if size.Value <= 0 {
+			return nil, fmt.Errorf("line %d: package size must be positive", line)
+		}
+
+		price, err := conversion.Numeral(strings.TrimSpace(row[3])).Float()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price '%s': %w", line, row[3], err)
+		}
+
+		l.Add(Price{
+			Name:  strings.TrimSpace(row[0]),
+			Size:  size,
+			Price: price,
+		})
+	}
+
+	return l, nil
+}
@@ -0,0 +1,466 @@
+// Package epub bundles recipes into an EPUB 3 e-book with a table of
+// contents, a chapter for every group of recipes and the images that
+// belong to them.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/ingredients"
+	"github.com/dememorized/cook/internal/load"
+	"github.com/dememorized/cook/renderer"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MediaType is the media type of EPUB files.
+const MediaType = "application/epub+zip"
+
+const container = `<?xml version="1.0" encoding="utf-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+
+//go:embed templates.xhtml
+var templatesRaw string
+var templates = htmltemplate.Must(htmltemplate.New("epub").Parse(templatesRaw))
+
+//go:embed content.opf
+var packageRaw string
+var packageTemplate = template.Must(template.New("content.opf").Funcs(template.FuncMap{
+	"x": func(s string) string {
+		b := strings.Builder{}
+		_ = xml.EscapeText(&b, []byte(s))
+		return b.String()
+	},
+}).Parse(packageRaw))
+
+//go:embed style.css
+var styleCSS []byte
+
+// imageTypes are the media types of the images that can be included
+// in a book, by extension.
+var imageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".svg":  "image/svg+xml",
+	".webp": "image/webp",
+}
+
+var labels = map[string]map[string]string{
+	"en": {
+		"contents":    "Contents",
+		"ingredients": "Ingredients",
+		"steps":       "Steps",
+	},
+	"sv": {
+		"contents":    "Innehåll",
+		"ingredients": "Ingredienser",
+		"steps":       "Gör så här",
+	},
+}
+
+// Book is a cookbook that can be written as an EPUB.
+type Book struct {
+	// Dir is the directory that the recipe, image and cover paths
+	// are relative to.
+	Dir         string
+	Title       string
+	Author      string
+	Language    string
+	Description string
+	// Identifier is a unique identifier for the book, such as an
+	// ISBN or a URL. Defaults to a UUID derived from the contents of
+	// the book.
+	Identifier string
+	// Cover is the path to the cover image.
+	Cover string
+	// Modified is when the book was last changed. Defaults to now.
+	Modified time.Time
+	Chapters []Chapter
+	// Options is used when rendering the recipes.
+	Options renderer.Options
+	// CSS replaces the default stylesheet.
+	CSS []byte
+}
+
+type item struct {
+	ID         string
+	Href       string
+	MediaType  string
+	Properties string
+}
+
+type recipeEntry struct {
+	File  string
+	Title string
+	path  string
+	ast   *aromalang.AST
+	image string
+}
+
+type chapterEntry struct {
+	File    string
+	Title   string
+	Recipes []*recipeEntry
+}
+
+type recipeData struct {
+	Title       string
+	Image       string
+	Metadata    []aromalang.Metadata
+	Ingredients []ingredientData
+	Body        htmltemplate.HTML
+}
+
+type ingredientData struct {
+	Name     string
+	Quantity string
+}
+
+type pageData struct {
+	Lang     string
+	Title    string
+	Labels   map[string]string
+	Cover    string
+	Chapters []*chapterEntry
+	Chapter  *chapterEntry
+	Recipe   *recipeData
+}
+
+// writer keeps track of the files that have been added to the book.
+type writer struct {
+	zip    *zip.Writer
+	book   *Book
+	fsys   fs.FS
+	items  []item
+	spine  []string
+	images map[string]string
+	hash   io.Writer
+}
+
+// Write loads the book's recipes from fsys and writes the book to w.
+func (b *Book) Write(w io.Writer, fsys fs.FS) error {
+	book := *b
+	if book.Dir == "" {
+		book.Dir = "."
+	}
+	if book.Title == "" {
+		book.Title = "Cookbook"
+	}
+	if book.Language == "" {
+		book.Language, _, _ = strings.Cut(book.Options.Locale, "-")
+	}
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	if book.Modified.IsZero() {
+		book.Modified = time.Now()
+	}
+
+	hash := sha256.New()
+	bw := &writer{
+		book:   &book,
+		fsys:   fsys,
+		images: map[string]string{},
+		hash:   hash,
+	}
+
+	chapters, err := bw.load()
+	if err != nil {
+		return err
+	}
+	if book.Identifier == "" {
+		sum := hash.Sum(nil)
+		sum[6] = sum[6]&0x0f | 0x50
+		sum[8] = sum[8]&0x3f | 0x80
+		book.Identifier = fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+	}
+
+	bw.zip = zip.NewWriter(w)
+
+	// The mimetype must be the first file in the archive, and must
+	// not be compressed.
+	f, err := bw.zip.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store, Modified: book.Modified})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, MediaType); err != nil {
+		return err
+	}
+	if err := bw.file("META-INF/container.xml", []byte(container)); err != nil {
+		return err
+	}
+
+	if err := bw.pages(chapters); err != nil {
+		return err
+	}
+
+	buf := bytes.Buffer{}
+	err = packageTemplate.Execute(&buf, map[string]any{
+		"Lang":        book.Language,
+		"Identifier":  book.Identifier,
+		"Title":       book.Title,
+		"Author":      book.Author,
+		"Description": book.Description,
+		"Modified":    book.Modified.UTC().Format("2006-01-02T15:04:05Z"),
+		"Cover":       book.Cover != "",
+		"Items":       bw.items,
+		"Spine":       bw.spine,
+	})
+	if err != nil {
+		return err
+	}
+	if err := bw.file("OEBPS/content.opf", buf.Bytes()); err != nil {
+		return err
+	}
+
+	return bw.zip.Close()
+}
+
+// load parses every recipe of the book and finds their images.
+func (w *writer) load() ([]*chapterEntry, error) {
+	fmt.Fprintln(w.hash, w.book.Title)
+
+	var chapters []*chapterEntry
+	n := 0
+	for i, c := range w.book.Chapters {
+		chapter := &chapterEntry{
+			File:  fmt.Sprintf("chapter-%d.xhtml", i+1),
+			Title: c.Title,
+		}
+		chapters = append(chapters, chapter)
+
+		for _, p := range c.Recipes {
+			n++
+			name := path.Join(w.book.Dir, p)
+			src, err := fs.ReadFile(w.fsys, name)
+			if err != nil {
+				return nil, w.errorf(c, err)
+			}
+			w.hash.Write(src)
+
+			ast, err := load.Parse(name, bytes.NewReader(src))
+			if err != nil {
+				return nil, w.errorf(c, err)
+			}
+			chapter.Recipes = append(chapter.Recipes, &recipeEntry{
+				File:  fmt.Sprintf("recipe-%d.xhtml", n),
+				Title: ast.Title(),
+				path:  name,
+				ast:   ast,
+			})
+		}
+	}
+	return chapters, nil
+}
+
+func (w *writer) errorf(c Chapter, err error) error {
+	if c.Pos.IsValid() {
+		return fmt.Errorf("%s: %w", c.Pos, err)
+	}
+	return err
+}
+
+func (w *writer) pages(chapters []*chapterEntry) error {
+	data := pageData{
+		Lang:     w.book.Language,
+		Title:    w.book.Title,
+		Labels:   labels["en"],
+		Chapters: chapters,
+	}
+	if l, ok := labels[w.book.Language]; ok {
+		data.Labels = l
+	}
+
+	css := w.book.CSS
+	if css == nil {
+		css = styleCSS
+	}
+	if err := w.add("style.css", "text/css", "", css); err != nil {
+		return err
+	}
+
+	if w.book.Cover != "" {
+		href, err := w.image(path.Join(w.book.Dir, w.book.Cover), "cover-image")
+		if err != nil {
+			return err
+		}
+		data.Cover = href
+		if err := w.page("cover.xhtml", "cover", "", data); err != nil {
+			return err
+		}
+	}
+
+	if err := w.page("nav.xhtml", "nav", "nav", data); err != nil {
+		return err
+	}
+
+	for _, c := range chapters {
+		if c.Title != "" {
+			d := data
+			d.Title = c.Title
+			d.Chapter = c
+			if err := w.page(c.File, "chapter", "", d); err != nil {
+				return err
+			}
+		}
+
+		for _, r := range c.Recipes {
+			recipe, err := w.recipe(r)
+			if err != nil {
+				return fmt.Errorf("%s: %w", r.path, err)
+			}
+			d := data
+			d.Title = r.Title
+			d.Recipe = recipe
+			if err := w.page(r.File, "recipe", "", d); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *writer) recipe(r *recipeEntry) (*recipeData, error) {
+	opts := w.book.Options
+
+	body := bytes.Buffer{}
+	if err := (renderer.HTML{AST: r.ast}).RenderTo(&body, opts); err != nil {
+		return nil, err
+	}
+
+	ast := opts.Apply(r.ast)
+	data := &recipeData{
+		Title: r.Title,
+		Body:  htmltemplate.HTML(body.String()),
+	}
+
+	for _, md := range ast.Recipe.Metadata {
+		switch strings.ToLower(md.Key) {
+		case "title", "image":
+			continue
+		}
+		data.Metadata = append(data.Metadata, md)
+	}
+
+	// The list is localized after the quantities are added up, since
+	// localized numbers can't be parsed.
+	list := &ingredients.List{}
+	list.AddRecipe(renderer.Options{Scale: opts.Scale, Units: opts.Units}.Apply(r.ast), 1)
+	for _, item := range list.Items() {
+		data.Ingredients = append(data.Ingredients, ingredientData{Name: item.Name, Quantity: opts.Quantity(item)})
+	}
+
+	if img := w.findImage(r); img != "" {
+		href, err := w.image(img, "")
+		if err != nil {
+			return nil, err
+		}
+		data.Image = href
+	}
+
+	return data, nil
+}
+
+// findImage returns the path to the image of a recipe, which is either
+// given by the "image" metadata or is a file next to the recipe with
+// the same name and an image extension. Images that aren't part of
+// fsys, such as URLs, are left out.
+func (w *writer) findImage(r *recipeEntry) string {
+	dir := path.Dir(r.path)
+	if img := strings.TrimSpace(r.ast.Metadata()["image"]); img != "" {
+		p := path.Join(dir, img)
+		if _, ok := imageTypes[strings.ToLower(path.Ext(p))]; ok && fs.ValidPath(p) {
+			if _, err := fs.Stat(w.fsys, p); err == nil {
+				return p
+			}
+		}
+	}
+
+	base := strings.TrimSuffix(r.path, path.Ext(r.path))
+	for _, ext := range []string{".jpg", ".jpeg", ".png", ".gif", ".svg", ".webp"} {
+		if _, err := fs.Stat(w.fsys, base+ext); err == nil {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// image adds the image at p in fsys to the book, unless it has already
+// been added, and returns its path within the book.
+func (w *writer) image(p string, properties string) (string, error) {
+	if href, ok := w.images[p]; ok {
+		return href, nil
+	}
+
+	mediaType, ok := imageTypes[strings.ToLower(path.Ext(p))]
+	if !ok {
+		return "", fmt.Errorf("unsupported image format '%s'", path.Ext(p))
+	}
+	b, err := fs.ReadFile(w.fsys, p)
+	if err != nil {
+		return "", err
+	}
+
+	href := fmt.Sprintf("images/%d%s", len(w.images)+1, strings.ToLower(path.Ext(p)))
+	if properties == "cover-image" {
+		href = "images/cover" + strings.ToLower(path.Ext(p))
+	}
+	w.images[p] = href
+
+	id := properties
+	if id == "" {
+		id = strings.NewReplacer("/", "-", ".", "-").Replace(href)
+	}
+	w.items = append(w.items, item{ID: id, Href: href, MediaType: mediaType, Properties: properties})
+	return href, w.file("OEBPS/"+href, b)
+}
+
+// page renders a XHTML document and adds it to the spine.
+func (w *writer) page(name, tmpl, properties string, data pageData) error {
+	buf := bytes.Buffer{}
+	// html/template would escape the XML declaration.
+	buf.WriteString(xml.Header)
+	if err := templates.ExecuteTemplate(&buf, tmpl, data); err != nil {
+		return err
+	}
+
+	w.spine = append(w.spine, strings.TrimSuffix(name, ".xhtml"))
+	return w.add(name, "application/xhtml+xml", properties, buf.Bytes())
+}
+
+func (w *writer) add(name, mediaType, properties string, b []byte) error {
+	w.items = append(w.items, item{
+		ID:         strings.NewReplacer("/", "-", ".", "-").Replace(strings.TrimSuffix(name, ".xhtml")),
+		Href:       name,
+		MediaType:  mediaType,
+		Properties: properties,
+	})
+	return w.file("OEBPS/"+name, b)
+}
+
+func (w *writer) file(name string, b []byte) error {
+	f, err := w.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: w.book.Modified})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	return err
+}
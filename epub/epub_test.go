@@ -0,0 +1,192 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func readZip(t *testing.T, b []byte) map[string]string {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if len(r.File) == 0 || r.File[0].Name != "mimetype" || r.File[0].Method != zip.Store {
+		t.Error("expected an uncompressed mimetype as the first file")
+	}
+
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+// wellFormed checks that a document is valid XML.
+func wellFormed(t *testing.T, name, doc string) {
+	if !strings.HasPrefix(doc, "<?xml") {
+		t.Errorf("%s has no XML declaration:\n%s", name, doc)
+	}
+	d := xml.NewDecoder(strings.NewReader(doc))
+	for {
+		_, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			t.Errorf("%s is not well-formed: %v\n%s", name, err, doc)
+			return
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	fsys := os.DirFS("testdata")
+	f, err := fsys.Open("cookbook.book")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	defer f.Close()
+
+	book, err := ParseManifest("cookbook.book", f)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	buf := bytes.Buffer{}
+	if err := book.Write(&buf, fsys); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	files := readZip(t, buf.Bytes())
+	for _, name := range []string{
+		"META-INF/container.xml",
+		"OEBPS/content.opf",
+		"OEBPS/nav.xhtml",
+		"OEBPS/cover.xhtml",
+		"OEBPS/chapter-1.xhtml",
+		"OEBPS/recipe-1.xhtml",
+		"OEBPS/recipe-2.xhtml",
+		"OEBPS/images/cover.gif",
+		"OEBPS/images/2.gif",
+	} {
+		doc, ok := files[name]
+		if !ok {
+			t.Errorf("expected %s in the book", name)
+			continue
+		}
+		if strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".xhtml") || strings.HasSuffix(name, ".opf") {
+			wellFormed(t, name, doc)
+		}
+	}
+
+	opf := files["OEBPS/content.opf"]
+	for _, expected := range []string{
+		"<dc:title>The family cookbook</dc:title>",
+		"<dc:creator>The Smiths &amp; co</dc:creator>",
+		`<meta property="dcterms:modified">2024-03-01T00:00:00Z</meta>`,
+		`properties="cover-image"`,
+		`<itemref idref="recipe-2"/>`,
+	} {
+		if !strings.Contains(opf, expected) {
+			t.Errorf("expected content.opf to contain %s, got:\n%s", expected, opf)
+		}
+	}
+
+	nav := files["OEBPS/nav.xhtml"]
+	if !strings.Contains(nav, `<a href="chapter-2.xhtml">Dinner</a>`) || !strings.Contains(nav, `<a href="recipe-2.xhtml">Tomato soup</a>`) {
+		t.Errorf("expected chapters and recipes in the table of contents, got:\n%s", nav)
+	}
+
+	pancakes := files["OEBPS/recipe-1.xhtml"]
+	if !strings.Contains(pancakes, `<img src="images/2.gif" alt="pancakes"/>`) || !strings.Contains(pancakes, `cook-ingredient`) {
+		t.Errorf("expected the recipe with its image, got:\n%s", pancakes)
+	}
+
+	// The identifier is derived from the contents of the book.
+	again := bytes.Buffer{}
+	if err := book.Write(&again, fsys); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.Contains(opf, "urn:uuid:") || !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("expected the same book to be written twice")
+	}
+}
+
+func TestWriteLocale(t *testing.T) {
+	fsys := fstest.MapFS{
+		"porridge.cook": {Data: []byte("Boil the @oats{1.5%dl} in @water{3%dl}, then add @oats{1.5%dl} more.\n")},
+	}
+	book := &Book{
+		Title:    "Frukost",
+		Modified: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Chapters: []Chapter{{Recipes: []string{"porridge.cook"}}},
+	}
+	book.Options.Locale = "sv"
+
+	buf := bytes.Buffer{}
+	if err := book.Write(&buf, fsys); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	// The amounts are added up before they are localized.
+	recipe := readZip(t, buf.Bytes())["OEBPS/recipe-1.xhtml"]
+	if !strings.Contains(recipe, `<span class="cook-quantity">3 dl</span> oats`) || strings.Contains(recipe, "1,5 dl</span> oats") {
+		t.Errorf("expected 3 dl oats in the ingredient list, got:\n%s", recipe)
+	}
+}
+
+func TestFromFolders(t *testing.T) {
+	book, err := FromFolders(os.DirFS("testdata"), ".")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if len(book.Chapters) != 2 || book.Chapters[0].Title != "" || book.Chapters[1].Title != "breakfast" {
+		t.Errorf("expected an untitled chapter and breakfast, got %+v", book.Chapters)
+		t.FailNow()
+	}
+	if book.Chapters[1].Recipes[0] != "breakfast/pancakes.cook" {
+		t.Errorf("expected paths relative to the directory, got %v", book.Chapters[1].Recipes)
+	}
+}
+
+func TestParseManifestErrors(t *testing.T) {
+	for _, manifest := range []string{
+		"pancakes.cook\n",
+		"[Breakfast\n",
+		">> colour: blue\n",
+		"[Breakfast]\nnotes.txt\n",
+	} {
+		_, err := ParseManifest("book", strings.NewReader(manifest))
+		if err == nil {
+			t.Errorf("expected error for %q", manifest)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package epub
+
+import (
+	"bufio"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/load"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/scanner"
+	"time"
+)
+
+// Chapter is a titled group of recipes in a book.
+type Chapter struct {
+	Pos   scanner.Position
+	Title string
+	// Recipes are the paths to the chapter's recipes, relative to the
+	// book's directory.
+	Recipes []string
+}
+
+// ParseManifest reads a cookbook manifest, which uses the same syntax
+// as a meal plan with chapters in place of days:
+//
+//	>> title: The family cookbook
+//	>> author: The Smiths
+//	>> cover: images/cover.jpg
+//
+//	[Breakfast]
+//	breakfast/pancakes.cook
+//	porridge.aroma -- grandma's
+//
+// The recognized metadata keys are title, author, language,
+// identifier, description, cover and modified (as YYYY-MM-DD).
+func ParseManifest(filename string, r io.Reader) (*Book, error) {
+	b := &Book{Dir: path.Dir(filename)}
+
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		pos := scanner.Position{Filename: filename, Line: lineNo, Column: 1}
+
+		line := s.Text()
+		if i := strings.Index(line, "--"); i != -1 {
+			line = line[:i]
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		pos.Column += indent
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ">>"):
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, ">>"), ":")
+			if !ok {
+				return nil, aromalang.NewErrorf(pos, "expected colon to separate metadata key and value")
+			}
+			if err := b.set(pos, strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, aromalang.NewErrorf(pos, "unclosed chapter header")
+			}
+			b.Chapters = append(b.Chapters, Chapter{
+				Pos:   pos,
+				Title: strings.TrimSpace(line[1 : len(line)-1]),
+			})
+		default:
+			if len(b.Chapters) == 0 {
+				return nil, aromalang.NewErrorf(pos, "recipe '%s' is not part of a chapter", line)
+			}
+			if !load.Supported(line) {
+				return nil, aromalang.NewErrorf(pos, "'%s' is not a recipe", line)
+			}
+			c := &b.Chapters[len(b.Chapters)-1]
+			c.Recipes = append(c.Recipes, line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *Book) set(pos scanner.Position, key, value string) error {
+	switch strings.ToLower(key) {
+	case "title":
+		b.Title = value
+	case "author":
+		b.Author = value
+	case "language", "lang":
+		b.Language = value
+	case "identifier", "isbn":
+		b.Identifier = value
+	case "description":
+		b.Description = value
+	case "cover":
+		b.Cover = value
+	case "modified", "date":
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return aromalang.NewErrorf(pos, "invalid date '%s', expected YYYY-MM-DD", value)
+		}
+		b.Modified = t
+	default:
+		return aromalang.NewErrorf(pos, "unknown metadata key '%s'", key)
+	}
+	return nil
+}
+
+// FromFolders creates a book from the recipes in dir, with a chapter
+// for every folder that contains recipes. Recipes directly within dir
+// are put first in a chapter without a title.
+func FromFolders(fsys fs.FS, dir string) (*Book, error) {
+	chapters := map[string]*Chapter{}
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != dir && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if d.IsDir() || !load.Supported(p) {
+			return nil
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, dir), "/")
+		if dir == "." {
+			rel = p
+		}
+		folder := path.Dir(rel)
+		c, ok := chapters[folder]
+		if !ok {
+			c = &Chapter{Pos: scanner.Position{Filename: path.Join(dir, folder)}}
+			if folder != "." {
+				c.Title = strings.ReplaceAll(folder, "/", " – ")
+			}
+			chapters[folder] = c
+		}
+		c.Recipes = append(c.Recipes, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make([]string, 0, len(chapters))
+	for f := range chapters {
+		folders = append(folders, f)
+	}
+	sort.Strings(folders)
+
+	b := &Book{Dir: dir}
+	for _, f := range folders {
+		c := chapters[f]
+		sort.Strings(c.Recipes)
+		b.Chapters = append(b.Chapters, *c)
+	}
+	return b, nil
+}
@@ -0,0 +1,60 @@
+package highlight
+
+import (
+	"io"
+	"strings"
+)
+
+const ansiReset = "\x1b[0m"
+
+// ansiStyles are the escape codes used by [ANSI] for every class.
+// Classes that aren't listed are written without color.
+var ansiStyles = map[string]string{
+	"tok-at":                  "\x1b[1;33m",
+	"tok-hash":                "\x1b[1;36m",
+	"tok-tilde":               "\x1b[1;35m",
+	"tok-double-greater-than": "\x1b[1;34m",
+	"tok-left-brace":          "\x1b[2m",
+	"tok-right-brace":         "\x1b[2m",
+	"tok-percent":             "\x1b[2m",
+	"tok-colon":               "\x1b[2m",
+	"tok-double-dash":         "\x1b[2m",
+	"tok-l-paren":             "\x1b[2m",
+	"tok-r-paren":             "\x1b[2m",
+	"tok-l-bracket":           "\x1b[2m",
+	"tok-r-bracket":           "\x1b[2m",
+	"tok-l-brace":             "\x1b[2m",
+	"tok-r-brace":             "\x1b[2m",
+	"tok-identifier":          "\x1b[1;34m",
+	"tok-atom":                "\x1b[35m",
+	"tok-string":              "\x1b[32m",
+	"tok-numeral":             "\x1b[33m",
+	"tok-invalid":             "\x1b[1;41m",
+}
+
+// ANSI writes the spans as text colored by ANSI escape codes. Styles
+// are reset at the end of every line, so that the output can be
+// combined with other text line by line.
+func ANSI(w io.Writer, spans []Span) error {
+	b := strings.Builder{}
+	for _, s := range spans {
+		style, ok := ansiStyles[s.Class]
+		if !ok {
+			b.WriteString(s.Text)
+			continue
+		}
+
+		lines := strings.Split(s.Text, "\n")
+		for i, line := range lines {
+			if i != 0 {
+				b.WriteByte('\n')
+			}
+			if line != "" {
+				b.WriteString(style + line + ansiReset)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
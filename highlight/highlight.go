@@ -0,0 +1,123 @@
+// Package highlight splits the source of .cook and .aroma files into
+// spans by token type, and writes them as HTML with a CSS class per
+// token type or as text colored by ANSI escape codes.
+package highlight
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/cooklang"
+	"github.com/dememorized/cook/internal/load"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Span is a piece of source code. Class is derived from the type of
+// the token, "tok-at" for [cooklang.TokenAt] and "tok-atom" for
+// [aromalang.TokenAtom], and is empty for whitespace and newlines.
+type Span struct {
+	Class string
+	Text  string
+}
+
+type token struct {
+	class  string
+	offset int
+}
+
+// Source splits src into spans using the syntax indicated by the
+// extension of filename.
+func Source(filename string, src []byte) ([]Span, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case load.ExtCooklang:
+		return Cooklang(src), nil
+	case load.ExtAroma:
+		return Aroma(src), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown recipe format '%s'", filename, path.Ext(filename))
+	}
+}
+
+// Cooklang splits Cooklang source into spans.
+func Cooklang(src []byte) []Span {
+	tokens, _ := cooklang.Tokenize("", bytes.NewReader(src))
+
+	list := make([]token, 0, len(tokens))
+	for _, t := range tokens {
+		list = append(list, token{class: class(t.Type.String()), offset: t.Position.Offset})
+	}
+	return spans(src, list)
+}
+
+// Aroma splits aromalang source into spans. Tokenizing stops at the
+// first error, and the rest of the source becomes a single span with
+// the class "tok-invalid".
+func Aroma(src []byte) []Span {
+	tokens, errs := aromalang.Tokenize("", bytes.NewReader(src))
+
+	list := make([]token, 0, len(tokens)+1)
+	for _, t := range tokens {
+		list = append(list, token{class: class(t.Type.String()), offset: t.Position.Offset})
+	}
+	if len(errs) != 0 && len(tokens) != 0 {
+		last := tokens[len(tokens)-1]
+		list = append(list, token{class: "tok-invalid", offset: end(last)})
+	} else if len(errs) != 0 {
+		list = append(list, token{class: "tok-invalid"})
+	}
+	return spans(src, list)
+}
+
+// end returns the offset just past an aromalang token. Tokens don't
+// keep their source, so it is scanned again from the token's start.
+func end(t aromalang.Token) int {
+	switch t.Type {
+	case aromalang.TokenString:
+		return t.Position.Offset + len(`"`+strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(t.Value)+`"`)
+	case aromalang.TokenAtom:
+		return t.Position.Offset + len(":"+t.Value)
+	default:
+		return t.Position.Offset + len(t.Value)
+	}
+}
+
+// spans cuts src at the offsets of the tokens, so that every byte of
+// the source ends up in exactly one span.
+func spans(src []byte, tokens []token) []Span {
+	res := make([]Span, 0, len(tokens))
+	for i, t := range tokens {
+		stop := len(src)
+		if i+1 < len(tokens) {
+			stop = tokens[i+1].offset
+		}
+		if t.offset >= stop {
+			continue
+		}
+		res = append(res, Span{Class: t.class, Text: string(src[t.offset:stop])})
+	}
+	if len(tokens) == 0 && len(src) != 0 {
+		res = append(res, Span{Text: string(src)})
+	}
+	return res
+}
+
+// class turns the name of a token type into a CSS class, such as
+// "DoubleDash" into "tok-double-dash".
+func class(name string) string {
+	switch name {
+	case "Whitespace", "NewLine", "EOF":
+		return ""
+	}
+
+	b := strings.Builder{}
+	b.WriteString("tok")
+	for i, r := range name {
+		if unicode.IsUpper(r) || i == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
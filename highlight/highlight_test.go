@@ -0,0 +1,122 @@
+package highlight
+
+import (
+	"bytes"
+	_ "embed"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/pancakes.cook
+var pancakesCooklang []byte
+
+//go:embed testdata/pancakes.aroma
+var pancakesAroma []byte
+
+func join(spans []Span) string {
+	b := strings.Builder{}
+	for _, s := range spans {
+		b.WriteString(s.Text)
+	}
+	return b.String()
+}
+
+func classes(spans []Span) map[string]string {
+	m := map[string]string{}
+	for _, s := range spans {
+		if _, ok := m[s.Class]; !ok {
+			m[s.Class] = s.Text
+		}
+	}
+	return m
+}
+
+func TestSource(t *testing.T) {
+	for name, src := range map[string][]byte{
+		"pancakes.cook":  pancakesCooklang,
+		"pancakes.aroma": pancakesAroma,
+	} {
+		spans, err := Source(name, src)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if join(spans) != string(src) {
+			t.Errorf("%s: expected the spans to add up to the source, got:\n%s", name, join(spans))
+		}
+	}
+
+	if _, err := Source("pancakes.txt", nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestClasses(t *testing.T) {
+	cook := classes(Cooklang(pancakesCooklang))
+	for class, text := range map[string]string{
+		"tok-at":                  "@",
+		"tok-tilde":               "~",
+		"tok-hash":                "#",
+		"tok-double-dash":         "--",
+		"tok-double-greater-than": ">>",
+		"tok-left-brace":          "{",
+	} {
+		if cook[class] != text {
+			t.Errorf("expected %s to be %q, got %q", class, text, cook[class])
+		}
+	}
+
+	aroma := classes(Aroma(pancakesAroma))
+	for class, text := range map[string]string{
+		"tok-l-paren":    "(",
+		"tok-identifier": "recipe",
+		"tok-atom":       ":quantity",
+		"tok-string":     `"source"`,
+	} {
+		if aroma[class] != text {
+			t.Errorf("expected %s to be %q, got %q", class, text, aroma[class])
+		}
+	}
+}
+
+func TestAromaInvalid(t *testing.T) {
+	src := `(step {} [(instruction "Mix \q well")])`
+	spans := Aroma([]byte(src))
+	if join(spans) != src {
+		t.Errorf("expected the spans to add up to the source, got %q", join(spans))
+	}
+	last := spans[len(spans)-1]
+	if last.Class != "tok-invalid" || !strings.HasPrefix(last.Text, `"Mix`) {
+		t.Errorf("expected the rest of the source to be invalid, got %+v", last)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	buf := bytes.Buffer{}
+	err := HTML(&buf, Cooklang([]byte("Add @salt{1%pinch} & stir.")))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	expected := `<pre class="cook-source"><code><span class="tok-text">Add</span> <span class="tok-at">@</span><span class="tok-text">salt</span><span class="tok-left-brace">{</span>` +
+		`<span class="tok-text">1</span><span class="tok-percent">%</span><span class="tok-text">pinch</span><span class="tok-right-brace">}</span> ` +
+		`<span class="tok-text">&amp;</span> <span class="tok-text">stir.</span></code></pre>` + "\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestANSI(t *testing.T) {
+	buf := bytes.Buffer{}
+	err := ANSI(&buf, Aroma([]byte("(instruction \"a\nb\")")))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	expected := "\x1b[2m(\x1b[0m\x1b[1;34minstruction\x1b[0m \x1b[32m\"a\x1b[0m\n\x1b[32mb\"\x1b[0m\x1b[2m)\x1b[0m"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,39 @@
+package highlight
+
+import (
+	"html/template"
+	"io"
+	"strings"
+)
+
+// CSS is a stylesheet for the classes used by [HTML].
+const CSS = `.cook-source { padding: 1em; background: #fbf8f4; color: #222; overflow-x: auto; }
+.tok-at, .tok-hash, .tok-tilde, .tok-double-greater-than { color: #b5542c; font-weight: bold; }
+.tok-left-brace, .tok-right-brace, .tok-percent, .tok-colon { color: #8a6d3b; }
+.tok-double-dash { color: #888; }
+.tok-l-paren, .tok-r-paren, .tok-l-bracket, .tok-r-bracket, .tok-l-brace, .tok-r-brace { color: #888; }
+.tok-identifier { color: #1f5f8b; font-weight: bold; }
+.tok-atom { color: #7b3f9e; }
+.tok-string { color: #2e7d32; }
+.tok-numeral { color: #b5542c; }
+.tok-invalid { color: #fff; background: #c0392b; }
+`
+
+// HTML writes the spans as a <pre> element, with a <span> for every
+// token that has a class.
+func HTML(w io.Writer, spans []Span) error {
+	b := strings.Builder{}
+	b.WriteString(`<pre class="cook-source"><code>`)
+	for _, s := range spans {
+		text := template.HTMLEscapeString(s.Text)
+		if s.Class == "" {
+			b.WriteString(text)
+			continue
+		}
+		b.WriteString(`<span class="` + s.Class + `">` + text + `</span>`)
+	}
+	b.WriteString("</code></pre>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
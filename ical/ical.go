@@ -0,0 +1,202 @@
+// Package ical exports recipe timelines as iCalendar (RFC 5545) files
+// that can be imported into most calendar applications.
+package ical
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/schedule"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	ProdID     = "-//dememorized//cook//EN"
+	timeFormat = "20060102T150405Z"
+	// maxLineLength is the maximum length of a content line in octets,
+	// excluding the line break.
+	maxLineLength = 75
+)
+
+// Calendar is an iCalendar file with one event for every step of a
+// timeline and one for every timer within those steps. Every event has
+// an alarm, which goes off when a step should be started or when a
+// timer is done.
+type Calendar struct {
+	Timeline schedule.Timeline
+	// Name is the name of the calendar shown by some applications.
+	Name string
+	// Stamp is the time the calendar was created. Defaults to now.
+	Stamp time.Time
+	// Domain is used to make the event UIDs globally unique.
+	// Defaults to "cook.invalid".
+	Domain string
+}
+
+// WriteTo writes the calendar to w.
+func (c Calendar) WriteTo(w io.Writer) (int64, error) {
+	buf := &bytes.Buffer{}
+	c.write(buf)
+	return buf.WriteTo(w)
+}
+
+// Render returns the calendar as a byte slice.
+func (c Calendar) Render() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	c.write(buf)
+	return buf.Bytes(), nil
+}
+
+func (c Calendar) write(buf *bytes.Buffer) {
+	if c.Stamp.IsZero() {
+		c.Stamp = time.Now()
+	}
+	if c.Domain == "" {
+		c.Domain = "cook.invalid"
+	}
+
+	line(buf, "BEGIN", "VCALENDAR")
+	line(buf, "VERSION", "2.0")
+	line(buf, "PRODID", ProdID)
+	line(buf, "CALSCALE", "GREGORIAN")
+	if c.Name != "" {
+		line(buf, "X-WR-CALNAME", text(c.Name))
+	}
+
+	for _, step := range c.Timeline.Steps {
+		title := step.Recipe.Title()
+		description := step.Step.Text()
+
+		c.event(buf, event{
+			uid:         c.uid(step.Recipe.Filename, step.Index, -1),
+			summary:     fmt.Sprintf("%s: step %d", title, step.Index+1),
+			description: description,
+			start:       step.Start,
+			end:         step.End,
+			duration:    step.Duration,
+			alarm:       "Time to start step " + fmt.Sprint(step.Index+1) + " of " + title,
+		})
+
+		for i, timer := range step.Timers {
+			name := timer.Timer.Name
+			if name == "" {
+				name = "timer"
+			}
+
+			c.event(buf, event{
+				uid:         c.uid(step.Recipe.Filename, step.Index, i),
+				summary:     fmt.Sprintf("%s: %s (%s %s)", title, name, timer.Timer.Magnitude, timer.Timer.Unit),
+				description: description,
+				start:       timer.Start,
+				end:         timer.End,
+				duration:    timer.Duration,
+				alarm:       capitalize(name) + " for " + title + " is done",
+				alarmAtEnd:  true,
+			})
+		}
+	}
+
+	line(buf, "END", "VCALENDAR")
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+type event struct {
+	uid         string
+	summary     string
+	description string
+	start       time.Time
+	end         time.Time
+	duration    conversion.TimeDiff
+	alarm       string
+	alarmAtEnd  bool
+}
+
+func (c Calendar) event(buf *bytes.Buffer, e event) {
+	line(buf, "BEGIN", "VEVENT")
+	line(buf, "UID", e.uid)
+	line(buf, "DTSTAMP", c.Stamp.UTC().Format(timeFormat))
+	line(buf, "DTSTART", e.start.UTC().Format(timeFormat))
+	if d, ok := Duration(e.duration); ok {
+		line(buf, "DURATION", d)
+	} else {
+		line(buf, "DTEND", e.end.UTC().Format(timeFormat))
+	}
+	line(buf, "SUMMARY", text(e.summary))
+	if e.description != "" {
+		line(buf, "DESCRIPTION", text(e.description))
+	}
+
+	line(buf, "BEGIN", "VALARM")
+	line(buf, "ACTION", "DISPLAY")
+	line(buf, "DESCRIPTION", text(e.alarm))
+	if e.alarmAtEnd {
+		line(buf, "TRIGGER;RELATED=END", "PT0S")
+	} else {
+		line(buf, "TRIGGER", "PT0S")
+	}
+	line(buf, "END", "VALARM")
+	line(buf, "END", "VEVENT")
+}
+
+// uid returns a stable identifier for a step or timer, so that
+// importing an updated timeline, even one served at another time,
+// replaces the events of the previous import rather than duplicating
+// them.
+func (c Calendar) uid(filename string, step, timer int) string {
+	h := sha1.Sum([]byte(fmt.Sprintf("%s\x00%d\x00%d", filename, step, timer)))
+	return fmt.Sprintf("%x@%s", h[:12], c.Domain)
+}
+
+// Duration formats d as an RFC 5545 duration. Months and years have
+// no representation in iCalendar, in which case false is returned and
+// the event's end time has to be used instead.
+func Duration(d conversion.TimeDiff) (string, bool) {
+	if d.Months != 0 || d.Years != 0 || d.Duration < 0 || d.Days < 0 {
+		return "", false
+	}
+	return d.ISO8601(), true
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+// text escapes a TEXT value.
+func text(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// line writes a content line, folding it into several lines if it's
+// longer than allowed without splitting any UTF-8 sequences.
+func line(buf *bytes.Buffer, name, value string) {
+	l := name + ":" + value
+	limit := maxLineLength
+	for len(l) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(l[cut]) {
+			cut--
+		}
+		buf.WriteString(l[:cut])
+		buf.WriteString("\r\n ")
+		l = l[cut:]
+		// Continuation lines start with a space, which counts
+		// towards the length of the line.
+		limit = maxLineLength - 1
+	}
+	buf.WriteString(l)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,121 @@
+package ical
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/schedule"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+const recipe = `(recipe {"title" "Marinated chicken"}
+[(step {} [(instruction "Marinate the ") (ingredient "chicken" {}) (instruction " for ") (timer "marinade" {:magnitude "1" :unit "day"})])
+(step {} [(instruction "Grill, turning once, for ") (timer "" {:magnitude "20" :unit "minutes"})])])`
+
+func TestCalendar(t *testing.T) {
+	ast, err := aromalang.Parse("chicken.aroma", strings.NewReader(recipe))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	serve := time.Date(2022, time.October, 23, 18, 0, 0, 0, time.UTC)
+	cal := Calendar{
+		Timeline: schedule.Backwards(serve, ast),
+		Stamp:    serve,
+	}
+
+	b, err := cal.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	fmt.Println(string(b))
+
+	ics := string(b)
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20221022T174000Z\r\nDURATION:P1D\r\n",
+		"SUMMARY:Marinated chicken: marinade (1 day)\r\n",
+		"TRIGGER;RELATED=END:PT0S\r\n",
+		"DESCRIPTION:Grill\\, turning once\\, for 20 minutes\r\n",
+	} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("expected calendar to contain %q", expected)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 4 || strings.Count(ics, "BEGIN:VALARM") != 4 {
+		t.Errorf("expected 4 events with alarms")
+	}
+	for _, l := range strings.Split(ics, "\r\n") {
+		if len(l) > maxLineLength {
+			t.Errorf("line is longer than %d octets: %q", maxLineLength, l)
+		}
+	}
+
+	// Moving the timeline keeps the events, so that importing it again
+	// replaces them.
+	cal.Timeline = schedule.Backwards(serve.Add(time.Hour), ast)
+	later, err := cal.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if uids(ics) != uids(string(later)) {
+		t.Errorf("expected the same UIDs, got:\n%s\nand:\n%s", uids(ics), uids(string(later)))
+	}
+}
+
+func uids(ics string) string {
+	var res []string
+	for _, l := range strings.Split(ics, "\r\n") {
+		if strings.HasPrefix(l, "UID:") {
+			res = append(res, l)
+		}
+	}
+	return strings.Join(res, "\n")
+}
+
+func TestCalendarUnicode(t *testing.T) {
+	ast, err := aromalang.Parse("ägg.aroma", strings.NewReader(`(recipe {"title" "Kokt ägg"}
+[(step {} [(instruction "Koka ") (timer "ägg" {:magnitude "8" :unit "minutes"})])])`))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	serve := time.Date(2022, time.October, 23, 8, 0, 0, 0, time.UTC)
+	b, err := Calendar{Timeline: schedule.Backwards(serve, ast), Stamp: serve}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !utf8.Valid(b) {
+		t.Errorf("expected valid UTF-8, got:\n%q", b)
+	}
+	if !strings.Contains(string(b), "DESCRIPTION:Ägg for Kokt ägg is done\r\n") {
+		t.Errorf("expected the timer name to be capitalized, got:\n%s", b)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := map[conversion.TimeDiff]string{
+		{Duration: 90 * time.Minute}:          "PT1H30M",
+		{Days: 2, Duration: 3 * time.Hour}:    "P2DT3H",
+		{Days: 7}:                             "P7D",
+		{}:                                    "PT0S",
+		{Duration: time.Hour + 5*time.Second}: "PT1H5S",
+	}
+
+	for d, expected := range tests {
+		if s, ok := Duration(d); !ok || s != expected {
+			t.Errorf("expected %v to be %s, got %s", d, expected, s)
+		}
+	}
+	if _, ok := Duration(conversion.TimeDiff{Months: 1}); ok {
+		t.Error("expected months to not be representable")
+	}
+}
@@ -0,0 +1,157 @@
+package ingredients
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Aisles maps ingredients to the aisle of the shop they are found in.
+// A nil Aisles has no aisles.
+type Aisles struct {
+	names    []string
+	aisles   map[string]int
+	synonyms *canonical.Dictionary
+}
+
+// LoadAisles reads an aisle configuration in the format used by
+// Cooklang's aisle.conf. Every aisle starts with its name within
+// brackets, followed by one ingredient per line. Other names of the
+// same ingredient can be listed on its line separated by |:
+//
+//	[fruit and veg]
+//	apple gala | apples
+//	aubergine
+//
+//	[milk and dairy]
+//	butter
+//
+// Empty lines and lines starting with # are ignored.
+func LoadAisles(r io.Reader) (*Aisles, error) {
+	a := &Aisles{
+		aisles:   map[string]int{},
+		synonyms: canonical.NewDictionary(),
+	}
+
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unclosed aisle name", lineNo)
+			}
+			a.names = append(a.names, strings.TrimSpace(line[1:len(line)-1]))
+		default:
+			if len(a.names) == 0 {
+				return nil, fmt.Errorf("line %d: ingredient '%s' is not in an aisle", lineNo, line)
+			}
+
+			names := strings.Split(line, "|")
+			a.synonyms.Add(names[0], names[1:]...)
+			a.aisles[a.synonyms.Key(names[0])] = len(a.names) - 1
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Aisle returns the name of the aisle of the ingredient, or the empty
+// string if it isn't in any aisle.
+func (a *Aisles) Aisle(name string) string {
+	if i, ok := a.aisle(name); ok {
+		return a.names[i]
+	}
+	return ""
+}
+
+func (a *Aisles) aisle(name string) (int, bool) {
+	if a == nil {
+		return 0, false
+	}
+	i, ok := a.aisles[a.synonyms.Key(name)]
+	return i, ok
+}
+
+// Names returns the names of the aisles in the order they were listed.
+func (a *Aisles) Names() []string {
+	if a == nil {
+		return nil
+	}
+	return append([]string{}, a.names...)
+}
+
+// Synonyms returns the names of the ingredients in the aisles as a
+// dictionary, so that a [List] can combine them.
+func (a *Aisles) Synonyms() *canonical.Dictionary {
+	if a == nil {
+		return nil
+	}
+	return a.synonyms
+}
+
+// Section is the items of a list that are in the same aisle.
+type Section struct {
+	// Aisle is empty for the items that aren't in any aisle.
+	Aisle string
+	Items []Item
+}
+
+// ByAisle groups the items of the list by aisle, in the order of the
+// aisles, with the items that aren't in any aisle last. The items of
+// every section are sorted by name. Ingredients are matched with the
+// aisles by the list's key, so that names the list combines are in
+// the same aisle.
+func (l *List) ByAisle(a *Aisles) []Section {
+	var sections []Section
+	for _, name := range a.Names() {
+		sections = append(sections, Section{Aisle: name})
+	}
+	other := len(sections)
+	sections = append(sections, Section{})
+
+	// index is the section of every ingredient in the aisles by its
+	// key in the list. If the list combines ingredients from several
+	// aisles, they are in the first of them.
+	index := map[string]int{}
+	if a != nil {
+		for k, i := range a.aisles {
+			lk := l.key(k)
+			if j, ok := index[lk]; !ok || i < j {
+				index[lk] = i
+			}
+		}
+	}
+
+	for _, item := range l.Sorted() {
+		i, ok := index[item.Key]
+		if !ok {
+			// Lists that don't use the synonyms of the aisles can
+			// still find ingredients by name.
+			i = other
+			if j, ok := a.aisle(item.Name); ok {
+				i = j
+			}
+		}
+		sections[i].Items = append(sections[i].Items, item)
+	}
+
+	res := sections[:0]
+	for _, s := range sections {
+		if len(s.Items) != 0 {
+			sort.SliceStable(s.Items, func(i, j int) bool {
+				return strings.ToLower(s.Items[i].Name) < strings.ToLower(s.Items[j].Name)
+			})
+			res = append(res, s)
+		}
+	}
+	return res
+}
@@ -0,0 +1,159 @@
+// Package ingredients combines the ingredients of one or more recipes
+// into a single list, adding up the quantities of ingredients that are
+// used more than once.
+package ingredients
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/conversion"
+	"sort"
+	"strings"
+)
+
+// Item is an ingredient in a List.
+type Item struct {
+	// Key identifies the ingredient in the list.
+	Key string
+	// Name is the name of the ingredient as it was first added.
+	Name string
+	// Amounts has one quantity per group of compatible units the
+	// ingredient is measured in.
+	Amounts []conversion.Quantity
+	// Notes are quantities that aren't numeric, such as "a handful".
+	Notes []string
+	// Unmeasured is true if the ingredient is used at least once
+	// without a quantity.
+	Unmeasured bool
+}
+
+func (i Item) String() string {
+	q := i.Quantity()
+	if q == "" {
+		return i.Name
+	}
+	return i.Name + ": " + q
+}
+
+// Quantity returns the amounts and notes of the item formatted as a
+// single string.
+func (i Item) Quantity() string {
+	parts := make([]string, 0, len(i.Amounts)+len(i.Notes))
+	for _, a := range i.Amounts {
+		parts = append(parts, a.String())
+	}
+	parts = append(parts, i.Notes...)
+	return strings.Join(parts, " + ")
+}
+
+// List is a combined list of ingredients. The zero value is ready to
+// use.
+type List struct {
+	// Key returns the key identifying an ingredient by its name.
+	// Ingredients with the same key are combined. Defaults to
+	// [canonical.Key], use [canonical.Dictionary.Key] to combine
+	// synonyms.
+	Key func(name string) string
+
+	items map[string]*Item
+	order []string
+}
+
+// AddRecipe adds every ingredient in the recipe scaled by scale.
+func (l *List) AddRecipe(ast *aromalang.AST, scale float64) {
+	for _, step := range ast.Recipe.Steps {
+		for _, ing := range step.Ingredients() {
+			l.Add(ing, scale)
+		}
+	}
+}
+
+// Add adds the ingredient, scaled by scale, to the list.
+func (l *List) Add(ing aromalang.Ingredient, scale float64) {
+	item := l.item(ing.Name)
+
+	if strings.TrimSpace(ing.Quantity) == "" {
+		item.Unmeasured = true
+		return
+	}
+
+	q, err := conversion.ParseQuantity(ing.Quantity, ing.Unit)
+	if err != nil {
+		note := strings.TrimSpace(ing.Quantity + " " + ing.Unit)
+		for _, n := range item.Notes {
+			if n == note {
+				return
+			}
+		}
+		item.Notes = append(item.Notes, note)
+		return
+	}
+
+	item.AddQuantity(q.Scale(scale))
+}
+
+// AddQuantity adds q to the first amount it's compatible with, or as a
+// new amount if there is none.
+func (i *Item) AddQuantity(q conversion.Quantity) {
+	for n, a := range i.Amounts {
+		if sum, err := a.Add(q); err == nil {
+			i.Amounts[n] = sum
+			return
+		}
+	}
+	i.Amounts = append(i.Amounts, q)
+}
+
+// Get returns the item with the given name.
+func (l *List) Get(name string) (Item, bool) {
+	item, ok := l.items[l.key(name)]
+	if !ok {
+		return Item{}, false
+	}
+	return *item, true
+}
+
+// Items returns the ingredients in the list in the order they were
+// first added.
+func (l *List) Items() []Item {
+	items := make([]Item, 0, len(l.order))
+	for _, k := range l.order {
+		items = append(items, *l.items[k])
+	}
+	return items
+}
+
+// Sorted returns the ingredients in the list sorted by name.
+func (l *List) Sorted() []Item {
+	items := l.Items()
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+func (l *List) Len() int {
+	return len(l.order)
+}
+
+func (l *List) key(name string) string {
+	if l.Key != nil {
+		return l.Key(name)
+	}
+	return canonical.Key(name)
+}
+
+func (l *List) item(name string) *Item {
+	if l.items == nil {
+		l.items = map[string]*Item{}
+	}
+
+	k := l.key(name)
+	item, ok := l.items[k]
+	if !ok {
+		item = &Item{Key: k, Name: strings.TrimSpace(name)}
+		l.items[k] = item
+		l.order = append(l.order, k)
+	}
+	return item
+}
@@ -0,0 +1,133 @@
+package ingredients
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dememorized/cook/internal/conversion"
+	"io"
+	"strings"
+)
+
+// PantryItem is an ingredient that is already at home.
+type PantryItem struct {
+	Name string
+	// Quantity is how much there is. Items without a quantity are
+	// assumed to be plenty.
+	Quantity *conversion.Quantity
+}
+
+// Pantry lists the ingredients that don't have to be bought.
+type Pantry struct {
+	Items []PantryItem
+}
+
+// LoadPantry reads a pantry file, with one ingredient per line and
+// optionally how much there is after a colon. Ingredients without an
+// amount are never put on the shopping list. Lines within brackets can
+// be used to group the ingredients by where they are kept:
+//
+//	[cupboard]
+//	flour: 1 kg
+//	salt
+//
+//	[fridge]
+//	milk: 1/2 l
+//
+// Empty lines and lines starting with # are ignored.
+func LoadPantry(r io.Reader) (*Pantry, error) {
+	p := &Pantry{}
+
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		name, amount, ok := strings.Cut(line, ":")
+		item := PantryItem{Name: strings.TrimSpace(name)}
+		if item.Name == "" {
+			return nil, fmt.Errorf("line %d: missing ingredient", lineNo)
+		}
+		if ok && strings.TrimSpace(amount) != "" {
+			q, err := parseAmount(amount)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			item.Quantity = &q
+		}
+		p.Items = append(p.Items, item)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// parseAmount reads an amount such as "500 g" or "1/2 cup", where the
+// unit is everything after the first space.
+func parseAmount(s string) (conversion.Quantity, error) {
+	value, unit, _ := strings.Cut(strings.TrimSpace(s), " ")
+	return conversion.ParseQuantity(value, unit)
+}
+
+// Subtract returns a copy of the list without what is in the pantry.
+// Amounts are reduced by compatible quantities in the pantry, and
+// having any of an ingredient covers its notes and unmeasured uses.
+// Items that are left with nothing to buy are removed.
+func (l *List) Subtract(p *Pantry) *List {
+	have := map[string][]PantryItem{}
+	if p != nil {
+		for _, item := range p.Items {
+			k := l.key(item.Name)
+			have[k] = append(have[k], item)
+		}
+	}
+
+	res := &List{Key: l.Key}
+	for _, item := range l.Items() {
+		stock, ok := have[item.Key]
+		if !ok {
+			res.put(item)
+			continue
+		}
+
+		item.Notes = nil
+		item.Unmeasured = false
+
+		amounts := append([]conversion.Quantity{}, item.Amounts...)
+		item.Amounts = nil
+		for _, a := range amounts {
+			for _, s := range stock {
+				if s.Quantity == nil {
+					a.Value = 0
+					break
+				}
+				if rest, err := a.Add(s.Quantity.Scale(-1)); err == nil {
+					a = rest
+				}
+			}
+			// Leave out what's left from rounding errors.
+			if a.Value > 1e-9 {
+				item.Amounts = append(item.Amounts, a)
+			}
+		}
+
+		if len(item.Amounts) != 0 {
+			res.put(item)
+		}
+	}
+	return res
+}
+
+// put adds an item that is already keyed to the list.
+func (l *List) put(item Item) {
+	if l.items == nil {
+		l.items = map[string]*Item{}
+	}
+	if _, ok := l.items[item.Key]; !ok {
+		l.order = append(l.order, item.Key)
+	}
+	l.items[item.Key] = &item
+}
@@ -0,0 +1,89 @@
+package ingredients
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/canonical"
+	"strings"
+	"testing"
+)
+
+func TestSubtractPantry(t *testing.T) {
+	p, err := LoadPantry(strings.NewReader(`
+# things at home
+[cupboard]
+salt
+flour: 1 cup
+sugar: 1 kg
+`))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	l := &List{}
+	l.Add(aromalang.Ingredient{Name: "Flour", Quantity: "500", Unit: "g"}, 1)
+	l.Add(aromalang.Ingredient{Name: "flour", Quantity: "2", Unit: "cups"}, 1)
+	l.Add(aromalang.Ingredient{Name: "salt", Quantity: "a pinch"}, 1)
+	l.Add(aromalang.Ingredient{Name: "sugar", Quantity: "100", Unit: "g"}, 1)
+	l.Add(aromalang.Ingredient{Name: "eggs", Quantity: "2"}, 1)
+
+	res := l.Subtract(p)
+	if res.Len() != 2 {
+		t.Errorf("expected flour and eggs to be left, got %v", res.Sorted())
+	}
+	if flour, _ := res.Get("flour"); flour.Quantity() != "500 g + 1 cup" {
+		t.Errorf("expected one cup of flour less, got '%s'", flour.Quantity())
+	}
+	if l.Len() != 4 {
+		t.Errorf("expected the original list to be unchanged, got %v", l.Sorted())
+	}
+}
+
+func TestAisles(t *testing.T) {
+	a, err := LoadAisles(strings.NewReader(`
+[produce]
+tomatoes | tomato
+[dairy]
+butter
+`))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	l := &List{Key: a.Synonyms().Key}
+	l.Add(aromalang.Ingredient{Name: "butter", Quantity: "50", Unit: "g"}, 1)
+	l.Add(aromalang.Ingredient{Name: "tomato", Quantity: "1"}, 1)
+	l.Add(aromalang.Ingredient{Name: "Tomatoes", Quantity: "2"}, 1)
+	l.Add(aromalang.Ingredient{Name: "basil"}, 1)
+
+	sections := l.ByAisle(a)
+	if len(sections) != 3 || sections[0].Aisle != "produce" || sections[1].Aisle != "dairy" || sections[2].Aisle != "" {
+		t.Errorf("expected produce, dairy and other sections, got %v", sections)
+		t.FailNow()
+	}
+	if item := sections[0].Items[0]; len(sections[0].Items) != 1 || item.Quantity() != "3" {
+		t.Errorf("expected tomatoes to be combined, got %v", sections[0].Items)
+	}
+
+	// Spring onions are only combined with scallions by the list's
+	// synonyms, which the aisles don't know about.
+	names := canonical.NewDictionary()
+	names.Add("scallion", "spring onion")
+	a, err = LoadAisles(strings.NewReader("[produce]\nscallion\n"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	l = &List{Key: func(name string) string { return names.Key(a.Synonyms().Key(name)) }}
+	l.Add(aromalang.Ingredient{Name: "spring onions", Quantity: "2"}, 1)
+	sections = l.ByAisle(a)
+	if len(sections) != 1 || sections[0].Aisle != "produce" {
+		t.Errorf("expected spring onions in produce, got %v", sections)
+	}
+
+	_, err = LoadAisles(strings.NewReader("butter\n"))
+	if err == nil || err.Error() != "line 1: ingredient 'butter' is not in an aisle" {
+		t.Errorf("expected error for ingredient outside aisle, got %v", err)
+	}
+}
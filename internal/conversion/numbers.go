@@ -8,7 +8,9 @@ import (
 
 type Numeral string
 
-var rationalNumberRegex = regexp.MustCompile("([0-9]+)\\s*/\\s*([0-9]+)")
+// rationalNumberRegex matches fractions such as "1/2", optionally with
+// a whole part as in "1 1/2".
+var rationalNumberRegex = regexp.MustCompile("(?:([0-9]+)\\s+)?([0-9]+)\\s*/\\s*([0-9]+)")
 
 func (n Numeral) Valid() bool {
 	_, err := n.Float()
@@ -43,14 +45,25 @@ func (n Numeral) rational() (*big.Rat, error) {
 		return nil, big.ErrNaN{}
 	}
 
-	a, err := strconv.ParseInt(sub[1], 10, 64)
+	a, err := strconv.ParseInt(sub[2], 10, 64)
 	if err != nil {
 		return nil, err
 	}
-	b, err := strconv.ParseInt(sub[2], 10, 64)
+	b, err := strconv.ParseInt(sub[3], 10, 64)
 	if err != nil {
 		return nil, err
 	}
+	if b == 0 {
+		return nil, big.ErrNaN{}
+	}
+	rat := big.NewRat(a, b)
 
-	return big.NewRat(a, b), nil
+	if sub[1] != "" {
+		whole, err := strconv.ParseInt(sub[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		rat.Add(rat, new(big.Rat).SetInt64(whole))
+	}
+	return rat, nil
 }
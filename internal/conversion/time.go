@@ -1,6 +1,12 @@
 package conversion
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
 
 var Durations = map[string]map[string]TimeDiff{
 	"": {
@@ -16,8 +22,14 @@ var Durations = map[string]map[string]TimeDiff{
 	"en": {
 		"second":  TimeDiff{Duration: time.Second},
 		"seconds": TimeDiff{Duration: time.Second},
+		"sec":     TimeDiff{Duration: time.Second},
+		"secs":    TimeDiff{Duration: time.Second},
+		"min":     TimeDiff{Duration: time.Minute},
+		"mins":    TimeDiff{Duration: time.Minute},
 		"minute":  TimeDiff{Duration: time.Minute},
 		"minutes": TimeDiff{Duration: time.Minute},
+		"hr":      TimeDiff{Duration: time.Hour},
+		"hrs":     TimeDiff{Duration: time.Hour},
 		"hour":    TimeDiff{Duration: time.Hour},
 		"hours":   TimeDiff{Duration: time.Hour},
 		"day":     TimeDiff{Days: 1},
@@ -70,8 +82,194 @@ func (d TimeDiff) AddTime(t time.Time) time.Time {
 // "wait three months" is unlikely to care about the exact length of
 // those months.
 func (d TimeDiff) ApproximateDuration() time.Duration {
-	return d.Duration +
-		time.Duration(d.Days)*24*time.Hour +
-		time.Duration(d.Months)*304/10*24*time.Hour +
-		time.Duration(d.Years)*36525/100*24*time.Hour
+	return clampDuration(d.approximate())
+}
+
+func (d TimeDiff) approximate() float64 {
+	day := float64(24 * time.Hour)
+	return float64(d.Duration) +
+		float64(d.Days)*day +
+		float64(d.Months)*30.4*day +
+		float64(d.Years)*365.25*day
+}
+
+// Add returns the sum of two TimeDiffs. If the days, months or years
+// don't fit, the sum of the approximate durations is returned instead,
+// which is clamped to the longest possible [time.Duration].
+func (d TimeDiff) Add(other TimeDiff) TimeDiff {
+	return newTimeDiff(
+		float64(d.Duration)+float64(other.Duration),
+		float64(d.Days)+float64(other.Days),
+		float64(d.Months)+float64(other.Months),
+		float64(d.Years)+float64(other.Years),
+		func() float64 {
+			return d.approximate() + other.approximate()
+		},
+	)
+}
+
+// Multiply scales the TimeDiff by factor. Whole factors keep days,
+// months, and years as they are, fractional factors fall back to the
+// approximate duration since half a month isn't a calendar concept.
+// Like [TimeDiff.Add], results that don't fit are approximated and
+// clamped.
+func (d TimeDiff) Multiply(factor float64) TimeDiff {
+	approximate := func() float64 {
+		return d.approximate() * factor
+	}
+	if factor != math.Trunc(factor) {
+		return TimeDiff{Duration: clampDuration(approximate())}
+	}
+	return newTimeDiff(
+		float64(d.Duration)*factor,
+		float64(d.Days)*factor,
+		float64(d.Months)*factor,
+		float64(d.Years)*factor,
+		approximate,
+	)
+}
+
+// newTimeDiff returns a TimeDiff with the given fields if they fit, or
+// with the clamped duration returned by approximate if they don't.
+func newTimeDiff(duration, days, months, years float64, approximate func() float64) TimeDiff {
+	if math.Abs(duration) >= math.MaxInt64 || math.Abs(days) > math.MaxInt16 ||
+		math.Abs(months) > math.MaxInt8 || math.Abs(years) > math.MaxInt8 {
+		return TimeDiff{Duration: clampDuration(approximate())}
+	}
+	return TimeDiff{
+		Duration: time.Duration(duration),
+		Days:     int16(days),
+		Months:   int8(months),
+		Years:    int8(years),
+	}
+}
+
+// maxDuration is the longest [time.Duration], about 292 years.
+const maxDuration = time.Duration(math.MaxInt64)
+
+func clampDuration(f float64) time.Duration {
+	switch {
+	case f >= math.MaxInt64:
+		return maxDuration
+	case f <= -math.MaxInt64:
+		return -maxDuration
+	}
+	return time.Duration(f)
+}
+
+// clamped returns true if the TimeDiff is too long to be represented.
+func (d TimeDiff) clamped() bool {
+	return d.Duration == maxDuration || d.Duration == -maxDuration
+}
+
+// Negate returns a TimeDiff that goes backwards in time by the same
+// amount.
+func (d TimeDiff) Negate() TimeDiff {
+	return TimeDiff{
+		Duration: -d.Duration,
+		Days:     -d.Days,
+		Months:   -d.Months,
+		Years:    -d.Years,
+	}
+}
+
+// ISO8601 formats the TimeDiff as an ISO 8601 duration, such as
+// "P1DT2H30M". Negative TimeDiffs are formatted with a leading minus
+// sign, which is a common extension to the standard.
+func (d TimeDiff) ISO8601() string {
+	if d.Duration < 0 || d.Days < 0 || d.Months < 0 || d.Years < 0 {
+		return "-" + d.Negate().ISO8601()
+	}
+
+	b := strings.Builder{}
+	b.WriteString("P")
+	if d.Years != 0 {
+		b.WriteString(fmt.Sprintf("%dY", d.Years))
+	}
+	if d.Months != 0 {
+		b.WriteString(fmt.Sprintf("%dM", d.Months))
+	}
+	if d.Days != 0 {
+		b.WriteString(fmt.Sprintf("%dD", d.Days))
+	}
+
+	secs := int64(d.Duration / time.Second)
+	if secs != 0 || b.Len() == 1 {
+		b.WriteString("T")
+		if h := secs / 3600; h != 0 {
+			b.WriteString(fmt.Sprintf("%dH", h))
+		}
+		if m := secs % 3600 / 60; m != 0 {
+			b.WriteString(fmt.Sprintf("%dM", m))
+		}
+		if s := secs % 60; s != 0 || secs == 0 {
+			b.WriteString(fmt.Sprintf("%dS", s))
+		}
+	}
+	return b.String()
+}
+
+// IsZero returns true if the TimeDiff doesn't change a time.
+func (d TimeDiff) IsZero() bool {
+	return d == TimeDiff{}
+}
+
+// LookupDuration finds the unit of time with the given name in any
+// language in [Durations]. Single letter abbreviations are case
+// sensitive since "m" and "M" are minutes and months respectively.
+func LookupDuration(unit string) (TimeDiff, bool) {
+	unit = strings.TrimSpace(unit)
+	if d, ok := Durations[""][unit]; ok {
+		return d, true
+	}
+
+	unit = strings.ToLower(unit)
+	for _, units := range Durations {
+		if d, ok := units[unit]; ok {
+			return d, true
+		}
+	}
+	return TimeDiff{}, false
+}
+
+// ParseDuration converts the magnitude and unit of a timer into a
+// TimeDiff.
+func ParseDuration(magnitude, unit string) (TimeDiff, error) {
+	n, err := Numeral(strings.TrimSpace(magnitude)).Float()
+	if err != nil {
+		return TimeDiff{}, fmt.Errorf("cannot parse magnitude '%s': %w", magnitude, err)
+	}
+
+	d, ok := LookupDuration(unit)
+	if !ok {
+		return TimeDiff{}, fmt.Errorf("unknown unit of time '%s'", unit)
+	}
+	if d = d.Multiply(n); d.clamped() {
+		return TimeDiff{}, fmt.Errorf("duration '%s %s' is too long", magnitude, unit)
+	}
+	return d, nil
+}
+
+var durationTextRegex = regexp.MustCompile(`((?:[0-9]+\s+)?[0-9]+\s*/\s*[0-9]+|[0-9]+(?:[.,][0-9]+)?)\s*([^\s0-9]+)`)
+
+// ParseDurationText reads free-form durations like "45 minutes",
+// "1 1/2 hours", "1 hour 30 minutes" or "1h30m", which is how
+// durations usually are written in recipe metadata.
+func ParseDurationText(s string) (TimeDiff, error) {
+	matches := durationTextRegex.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return TimeDiff{}, fmt.Errorf("cannot parse duration '%s'", s)
+	}
+
+	var total TimeDiff
+	for _, m := range matches {
+		d, err := ParseDuration(strings.ReplaceAll(m[1], ",", "."), strings.Trim(m[2], ".,"))
+		if err != nil {
+			return TimeDiff{}, err
+		}
+		if total = total.Add(d); total.clamped() {
+			return TimeDiff{}, fmt.Errorf("duration '%s' is too long", s)
+		}
+	}
+	return total, nil
 }
@@ -0,0 +1,53 @@
+package conversion
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseDurationText(t *testing.T) {
+	tests := map[string]TimeDiff{
+		"45 minutes":        {Duration: 45 * time.Minute},
+		"1 1/2 hours":       {Duration: 90 * time.Minute},
+		"1/2 hour":          {Duration: 30 * time.Minute},
+		"1 hour 30 minutes": {Duration: 90 * time.Minute},
+		"1h30m":             {Duration: 90 * time.Minute},
+		"2 days":            {Days: 2},
+	}
+	for s, expected := range tests {
+		d, err := ParseDurationText(s)
+		if err != nil || d != expected {
+			t.Errorf("expected '%s' to be %v, got %v: %v", s, expected, d, err)
+		}
+	}
+
+	for _, s := range []string{"1000 years", "200 years 200 years", "9999999999 hours"} {
+		if d, err := ParseDurationText(s); err == nil {
+			t.Errorf("expected '%s' to be too long, got %v", s, d)
+		}
+	}
+}
+
+func TestTimeDiffOverflow(t *testing.T) {
+	d := TimeDiff{Days: 300}.Multiply(200)
+	if d != (TimeDiff{Duration: 60000 * 24 * time.Hour}) {
+		t.Errorf("expected 60000 days to be approximated, got %v", d)
+	}
+
+	d = TimeDiff{Years: 100}.Multiply(100)
+	if d != (TimeDiff{Duration: time.Duration(math.MaxInt64)}) {
+		t.Errorf("expected 10000 years to be clamped, got %v", d)
+	}
+
+	month := TimeDiff{Months: 1}.ApproximateDuration()
+	d = TimeDiff{Months: 100}.Add(TimeDiff{Months: 100})
+	if d.Months != 0 || d.Duration.Round(time.Second) != (200*month).Round(time.Second) {
+		t.Errorf("expected 200 months to be approximated, got %v", d)
+	}
+
+	d = TimeDiff{Days: 2}.Multiply(3)
+	if d != (TimeDiff{Days: 6}) {
+		t.Errorf("expected whole factors to keep days, got %v", d)
+	}
+}
@@ -0,0 +1,322 @@
+package conversion
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Dimension is what a Unit measures. Quantities can only be converted
+// between units of the same dimension, with the exception of mass and
+// volume which can be bridged by a density.
+type Dimension int8
+
+const (
+	DimensionNone Dimension = iota
+	DimensionMass
+	DimensionVolume
+)
+
+func (d Dimension) String() string {
+	switch d {
+	case DimensionNone:
+		return "None"
+	case DimensionMass:
+		return "Mass"
+	case DimensionVolume:
+		return "Volume"
+	default:
+		return fmt.Sprintf("Unknown(%d)", d)
+	}
+}
+
+// System is a family of units that belong together, used to present
+// every quantity in a recipe in the same kind of units.
+type System int8
+
+const (
+	SystemAny System = iota
+	SystemMetric
+	SystemImperial
+)
+
+func (s System) String() string {
+	switch s {
+	case SystemAny:
+		return "any"
+	case SystemMetric:
+		return "metric"
+	case SystemImperial:
+		return "imperial"
+	default:
+		return fmt.Sprintf("unknown(%d)", s)
+	}
+}
+
+// ParseSystem returns the System with the given name, as returned by
+// [System.String]. The empty string is [SystemAny].
+func ParseSystem(name string) (System, error) {
+	switch strings.ToLower(name) {
+	case "", "any":
+		return SystemAny, nil
+	case "metric":
+		return SystemMetric, nil
+	case "imperial", "us":
+		return SystemImperial, nil
+	default:
+		return SystemAny, fmt.Errorf("unknown unit system '%s'", name)
+	}
+}
+
+// Unit is a unit of measurement for ingredients. Base is the size of
+// the unit in grams for mass units and in millilitres for volume
+// units. Units without a dimension (pinches, cans, sprigs) only
+// compare equal to units with the same symbol.
+type Unit struct {
+	Symbol    string
+	Dimension Dimension
+	System    System
+	Base      float64
+}
+
+var (
+	Milligram = Unit{Symbol: "mg", Dimension: DimensionMass, System: SystemMetric, Base: 0.001}
+	Gram      = Unit{Symbol: "g", Dimension: DimensionMass, System: SystemMetric, Base: 1}
+	Kilogram  = Unit{Symbol: "kg", Dimension: DimensionMass, System: SystemMetric, Base: 1000}
+	Ounce     = Unit{Symbol: "oz", Dimension: DimensionMass, System: SystemImperial, Base: 28.349523125}
+	Pound     = Unit{Symbol: "lb", Dimension: DimensionMass, System: SystemImperial, Base: 453.59237}
+
+	Millilitre   = Unit{Symbol: "ml", Dimension: DimensionVolume, System: SystemMetric, Base: 1}
+	Centilitre   = Unit{Symbol: "cl", Dimension: DimensionVolume, System: SystemMetric, Base: 10}
+	Decilitre    = Unit{Symbol: "dl", Dimension: DimensionVolume, System: SystemMetric, Base: 100}
+	Litre        = Unit{Symbol: "l", Dimension: DimensionVolume, System: SystemMetric, Base: 1000}
+	Teaspoon     = Unit{Symbol: "tsp", Dimension: DimensionVolume, System: SystemImperial, Base: 4.92892159375}
+	Tablespoon   = Unit{Symbol: "tbsp", Dimension: DimensionVolume, System: SystemImperial, Base: 14.78676478125}
+	FluidOunce   = Unit{Symbol: "fl oz", Dimension: DimensionVolume, System: SystemImperial, Base: 29.5735295625}
+	Cup          = Unit{Symbol: "cup", Dimension: DimensionVolume, System: SystemImperial, Base: 236.5882365}
+	Pint         = Unit{Symbol: "pt", Dimension: DimensionVolume, System: SystemImperial, Base: 473.176473}
+	Quart        = Unit{Symbol: "qt", Dimension: DimensionVolume, System: SystemImperial, Base: 946.352946}
+	Gallon       = Unit{Symbol: "gal", Dimension: DimensionVolume, System: SystemImperial, Base: 3785.411784}
+	MetricSpoon  = Unit{Symbol: "msk", Dimension: DimensionVolume, System: SystemMetric, Base: 15}
+	MetricTsp    = Unit{Symbol: "tsk", Dimension: DimensionVolume, System: SystemMetric, Base: 5}
+	SpiceMeasure = Unit{Symbol: "krm", Dimension: DimensionVolume, System: SystemMetric, Base: 1}
+)
+
+// Units maps the unit names used in recipes to their Unit, grouped by
+// language in the same way as [Durations]. Lookups are case-insensitive
+// through [LookupUnit].
+var Units = map[string]map[string]Unit{
+	"": {
+		"mg":    Milligram,
+		"g":     Gram,
+		"kg":    Kilogram,
+		"oz":    Ounce,
+		"lb":    Pound,
+		"lbs":   Pound,
+		"ml":    Millilitre,
+		"cl":    Centilitre,
+		"dl":    Decilitre,
+		"l":     Litre,
+		"tsp":   Teaspoon,
+		"tbsp":  Tablespoon,
+		"tbs":   Tablespoon,
+		"fl oz": FluidOunce,
+		"floz":  FluidOunce,
+		"c":     Cup,
+		"pt":    Pint,
+		"qt":    Quart,
+		"gal":   Gallon,
+	},
+	"en": {
+		"milligram":    Milligram,
+		"milligrams":   Milligram,
+		"gram":         Gram,
+		"grams":        Gram,
+		"gramme":       Gram,
+		"grammes":      Gram,
+		"kilogram":     Kilogram,
+		"kilograms":    Kilogram,
+		"ounce":        Ounce,
+		"ounces":       Ounce,
+		"pound":        Pound,
+		"pounds":       Pound,
+		"millilitre":   Millilitre,
+		"millilitres":  Millilitre,
+		"milliliter":   Millilitre,
+		"milliliters":  Millilitre,
+		"centilitre":   Centilitre,
+		"centilitres":  Centilitre,
+		"decilitre":    Decilitre,
+		"decilitres":   Decilitre,
+		"litre":        Litre,
+		"litres":       Litre,
+		"liter":        Litre,
+		"liters":       Litre,
+		"teaspoon":     Teaspoon,
+		"teaspoons":    Teaspoon,
+		"tablespoon":   Tablespoon,
+		"tablespoons":  Tablespoon,
+		"fluid ounce":  FluidOunce,
+		"fluid ounces": FluidOunce,
+		"cup":          Cup,
+		"cups":         Cup,
+		"pint":         Pint,
+		"pints":        Pint,
+		"quart":        Quart,
+		"quarts":       Quart,
+		"gallon":       Gallon,
+		"gallons":      Gallon,
+	},
+	"sv": {
+		"gram":       Gram,
+		"hekto":      {Symbol: "hg", Dimension: DimensionMass, System: SystemMetric, Base: 100},
+		"hg":         {Symbol: "hg", Dimension: DimensionMass, System: SystemMetric, Base: 100},
+		"kilo":       Kilogram,
+		"liter":      Litre,
+		"deciliter":  Decilitre,
+		"milliliter": Millilitre,
+		"msk":        MetricSpoon,
+		"matsked":    MetricSpoon,
+		"matskedar":  MetricSpoon,
+		"tsk":        MetricTsp,
+		"tesked":     MetricTsp,
+		"teskedar":   MetricTsp,
+		"krm":        SpiceMeasure,
+		"kryddmått":  SpiceMeasure,
+	},
+}
+
+// LookupUnit finds the Unit with the given name in any language. Unit
+// names that aren't known are returned as a dimensionless Unit with
+// the name as its symbol and false.
+func LookupUnit(name string) (Unit, bool) {
+	key := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	for _, units := range Units {
+		if u, ok := units[key]; ok {
+			return u, true
+		}
+	}
+	return Unit{Symbol: strings.TrimSpace(name), Base: 1}, false
+}
+
+// Quantity is an amount of a Unit.
+type Quantity struct {
+	Value float64
+	Unit  Unit
+}
+
+// ParseQuantity reads the quantity and unit strings of an ingredient.
+// Unknown units are kept as dimensionless units.
+func ParseQuantity(quantity, unit string) (Quantity, error) {
+	v, err := Numeral(strings.TrimSpace(quantity)).Float()
+	if err != nil {
+		return Quantity{}, fmt.Errorf("cannot parse quantity '%s': %w", quantity, err)
+	}
+
+	u, _ := LookupUnit(unit)
+	return Quantity{Value: v, Unit: u}, nil
+}
+
+// Compatible returns true if the two quantities can be added to each
+// other or converted between each other without any density.
+func (q Quantity) Compatible(other Quantity) bool {
+	if q.Unit.Dimension == DimensionNone || other.Unit.Dimension == DimensionNone {
+		return q.Unit.Dimension == other.Unit.Dimension &&
+			strings.EqualFold(q.Unit.Symbol, other.Unit.Symbol)
+	}
+	return q.Unit.Dimension == other.Unit.Dimension
+}
+
+// In converts the quantity into the given unit.
+func (q Quantity) In(unit Unit) (Quantity, error) {
+	if !q.Compatible(Quantity{Unit: unit}) {
+		return Quantity{}, fmt.Errorf("cannot convert '%s' to '%s'", q.Unit.Symbol, unit.Symbol)
+	}
+	return Quantity{Value: q.Value * q.Unit.Base / unit.Base, Unit: unit}, nil
+}
+
+// Add returns the sum of two compatible quantities in the unit of q.
+func (q Quantity) Add(other Quantity) (Quantity, error) {
+	o, err := other.In(q.Unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Value: q.Value + o.Value, Unit: q.Unit}, nil
+}
+
+// Scale multiplies the quantity by factor.
+func (q Quantity) Scale(factor float64) Quantity {
+	return Quantity{Value: q.Value * factor, Unit: q.Unit}
+}
+
+// Grams returns the mass of the quantity in grams. Volumes are
+// converted using density, given in grams per millilitre. A density of
+// zero means that the density is unknown, in which case only mass
+// quantities can be converted.
+func (q Quantity) Grams(density float64) (float64, bool) {
+	switch q.Unit.Dimension {
+	case DimensionMass:
+		return q.Value * q.Unit.Base, true
+	case DimensionVolume:
+		if density <= 0 {
+			return 0, false
+		}
+		return q.Value * q.Unit.Base * density, true
+	default:
+		return 0, false
+	}
+}
+
+var systemUnits = map[System]map[Dimension][]Unit{
+	SystemMetric: {
+		DimensionMass:   {Milligram, Gram, Kilogram},
+		DimensionVolume: {Millilitre, Decilitre, Litre},
+	},
+	SystemImperial: {
+		DimensionMass:   {Ounce, Pound},
+		DimensionVolume: {Teaspoon, Tablespoon, Cup, Quart, Gallon},
+	},
+}
+
+// To converts the quantity to the most readable unit of system, which
+// is the largest unit for which the value is at least one. Quantities
+// without a dimension and conversions to [SystemAny] are returned
+// unchanged.
+func (q Quantity) To(system System) Quantity {
+	units := systemUnits[system][q.Unit.Dimension]
+	if len(units) == 0 || q.Unit.System == system {
+		return q
+	}
+
+	best := units[0]
+	base := q.Value * q.Unit.Base
+	for _, u := range units {
+		if math.Abs(base)/u.Base >= 1 {
+			best = u
+		}
+	}
+
+	res, err := q.In(best)
+	if err != nil {
+		return q
+	}
+	return res
+}
+
+// String formats the quantity as "value unit", leaving out the unit
+// if it is empty.
+func (q Quantity) String() string {
+	v := FormatFloat(q.Value)
+	if q.Unit.Symbol == "" {
+		return v
+	}
+	return v + " " + q.Unit.Symbol
+}
+
+// FormatFloat formats a float with at most two decimals and without
+// trailing zeroes, which is about as precise as anyone measures in a
+// kitchen.
+func FormatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,250 @@
+package cooklang
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"sort"
+	"strings"
+)
+
+// FormatTokens writes a tokenized Cooklang recipe in the canonical
+// style. Unlike [Printer], it works from the tokens rather than the AST,
+// so that the line breaks, blank lines and comments of the source are
+// kept where they are. Whitespace within lines is collapsed, braces are
+// dropped where they aren't needed and the metadata at the top of the
+// recipe is sorted with [aromalang.SortMetadata].
+//
+// A blank line is only kept where it doesn't split a step, and the
+// result is parsed again to make sure that it is the same recipe.
+func FormatTokens(filename string, tokens []Token) (string, error) {
+	ast, spans, err := parse(filename, tokens)
+	if err != nil {
+		return "", err
+	}
+
+	f := formatter{ast: ast, tokens: tokens}
+	f.format(spans)
+	res := f.out.String()
+	if res != "" {
+		res += "\n"
+	}
+
+	again, errs := Tokenize(filename, strings.NewReader(res))
+	if len(errs) != 0 {
+		return "", fmt.Errorf("%s: formatting the recipe would make it invalid: %s", filename, errs[0].Message)
+	}
+	formatted, err := Parse(filename, again)
+	if err != nil {
+		return "", fmt.Errorf("%s: formatting the recipe would make it invalid: %w", filename, err)
+	}
+	if summary(ast) != summary(formatted) {
+		return "", fmt.Errorf("%s: formatting the recipe would change it", filename)
+	}
+	return res, nil
+}
+
+type formatter struct {
+	ast    *aromalang.AST
+	tokens []Token
+	out    strings.Builder
+
+	// The line breaks and space since the last thing written, and
+	// whether a new step starts there.
+	newlines int
+	space    bool
+	brk      bool
+
+	// The step that is being written and its components so far, and
+	// whether text has been written in it.
+	step  int
+	comps []aromalang.Component
+	text  bool
+}
+
+func (f *formatter) format(spans []span) {
+	pos := 0
+	header := []span{}
+	for i, s := range spans {
+		// The tokens between components are line breaks.
+		for ; pos < s.start; pos++ {
+			f.whitespace(f.tokens[pos])
+		}
+		pos = s.end
+
+		switch c := s.comp.(type) {
+		case nil:
+			f.brk = true
+			f.step++
+			f.comps, f.text = nil, false
+		case aromalang.Metadata:
+			if f.out.Len() == 0 && len(f.comps) == 0 {
+				header = append(header, s)
+				if i+1 < len(spans) {
+					if _, ok := spans[i+1].comp.(aromalang.Metadata); ok {
+						continue
+					}
+				}
+				f.header(header)
+				continue
+			}
+			f.metadata(s, c)
+		default:
+			f.component(s, c)
+			f.comps = append(f.comps, c)
+		}
+	}
+}
+
+// header writes the metadata at the top of the recipe sorted, each on
+// a line of its own.
+func (f *formatter) header(spans []span) {
+	md := make([]aromalang.Metadata, 0, len(spans))
+	byOffset := map[int]span{}
+	for _, s := range spans {
+		md = append(md, s.comp.(aromalang.Metadata))
+		byOffset[s.comp.Position().Offset] = s
+	}
+
+	for i, m := range aromalang.SortMetadata(md) {
+		if i != 0 {
+			f.newlines = 1
+		}
+		f.metadata(byOffset[m.Position().Offset], m)
+	}
+}
+
+func (f *formatter) metadata(s span, md aromalang.Metadata) {
+	if raw := f.raw(s); strings.Contains(raw, "\n") {
+		f.write(raw)
+		return
+	}
+	f.write(">> " + strings.TrimSpace(md.Key) + ": " + strings.TrimSpace(md.Value))
+}
+
+func (f *formatter) component(s span, c aromalang.Component) {
+	next := f.ast.Recipe.Steps[f.step].Components[len(f.comps)+1:]
+
+	switch c := c.(type) {
+	case aromalang.Instruction:
+		for _, tok := range f.tokens[s.start:s.end] {
+			switch {
+			case tok.Type == TokenWhitespace:
+				f.whitespace(tok)
+			case tok.Type == TokenDoubleGT && f.lineStart():
+				// Metadata only starts at the beginning of a line.
+				f.write(" " + tok.Value)
+			default:
+				f.write(tok.Value)
+			}
+			f.text = f.text || tok.Type != TokenWhitespace
+		}
+	case aromalang.Ingredient:
+		text := "@" + c.Name
+		if c.Quantity != "" || c.Unit != "" || printer.needsBraces(c.Name, next, c.Position().Line) {
+			text += "{" + amount(c.Quantity, c.Unit) + "}"
+		}
+		f.write(text)
+	case aromalang.Cookware:
+		text := "#" + c.Name
+		if printer.needsBraces(c.Name, next, c.Position().Line) {
+			text += "{}"
+		}
+		f.write(text)
+	case aromalang.Timer:
+		f.write("~" + c.Name + "{" + amount(c.Magnitude, c.Unit) + "}")
+	case aromalang.Comment:
+		// A comment runs until the end of the line, so one that has
+		// taken in line breaks is written as it was.
+		if raw := f.raw(s); strings.Contains(raw, "\n") {
+			f.write(raw)
+			return
+		}
+		f.space = true
+		f.write(strings.TrimSpace("-- " + c.Comment))
+	}
+}
+
+var printer = Printer{KeepLines: true}
+
+// whitespace takes note of the space and line breaks in tok, which are
+// written together with whatever comes after them.
+func (f *formatter) whitespace(tok Token) {
+	switch tok.Type {
+	case TokenNewLine:
+		f.newlines++
+	case TokenWhitespace:
+		if n := strings.Count(tok.Value, "\n"); n != 0 {
+			f.newlines += n
+		} else {
+			f.space = true
+		}
+	default:
+		f.space = true
+	}
+}
+
+func (f *formatter) lineStart() bool {
+	return f.out.Len() == 0 || f.newlines != 0 || f.brk
+}
+
+// write writes s after the space and line breaks before it. A blank
+// line starts a new step in Cooklang, so it is only written where one
+// does, or where the step has nothing that would end there yet.
+func (f *formatter) write(s string) {
+	if f.out.Len() != 0 {
+		switch {
+		case f.brk || f.newlines > 1 && !f.text && !(aromalang.Step{Components: f.comps}).HasInstructions():
+			f.out.WriteString("\n\n")
+		case f.newlines != 0:
+			f.out.WriteString("\n")
+		case f.space:
+			f.out.WriteString(" ")
+		}
+	}
+	f.newlines, f.space, f.brk = 0, false, false
+	f.out.WriteString(s)
+}
+
+// raw returns the source of the span.
+func (f *formatter) raw(s span) string {
+	b := strings.Builder{}
+	for _, tok := range f.tokens[s.start:s.end] {
+		b.WriteString(tok.Value)
+	}
+	return b.String()
+}
+
+// summary describes a recipe without the whitespace in it, for telling
+// whether formatting changed it. Text that is split over several lines
+// is read as several instructions, so they are joined.
+func summary(ast *aromalang.AST) string {
+	var lines []string
+	for _, m := range ast.Recipe.Metadata {
+		lines = append(lines, m.String())
+	}
+	sort.Strings(lines)
+
+	for _, step := range ast.Recipe.Steps {
+		lines = append(lines, "step")
+		text := ""
+		for _, c := range step.Components {
+			if i, ok := c.(aromalang.Instruction); ok {
+				text += i.Instruction
+				continue
+			}
+			if text != "" {
+				lines = append(lines, "instruction "+text)
+				text = ""
+			}
+			lines = append(lines, fmt.Sprintf("%T %v", c, c))
+		}
+		if text != "" {
+			lines = append(lines, "instruction "+text)
+		}
+	}
+
+	for i, l := range lines {
+		lines[i] = strings.Join(strings.Fields(l), "")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,58 @@
+package cooklang
+
+import (
+	"strings"
+	"testing"
+)
+
+func formatString(t *testing.T, src string) string {
+	tokens, errs := Tokenize("tea.cook", strings.NewReader(src))
+	if len(errs) != 0 {
+		t.Error(errs)
+		t.FailNow()
+	}
+	res, err := FormatTokens("tea.cook", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return res
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		src, expected string
+	}{
+		{
+			// A comment before a blank line doesn't end a step, but
+			// the blank line stays.
+			src:      "-- intro note\n\nMix @flour{100%g}.\n",
+			expected: "-- intro note\n\nMix @flour{100%g}.\n",
+		},
+		{
+			src:      ">> servings: 2\n>>   title :  Tea\n\n\n\nBoil   @water{500%ml} in a #kettle{}.\nAdd @tea{}   and wait ~{3%minutes}.   -- not longer\n\n\n-- serve hot\nServe with @milk{} and @honey{}, or @sugar{1%tsp}\n",
+			expected: ">> title: Tea\n>> servings: 2\n\nBoil @water{500%ml} in a #kettle{}.\nAdd @tea and wait ~{3%minutes}. -- not longer\n\n-- serve hot\nServe with @milk and @honey{}, or @sugar{1%tsp}\n",
+		},
+		{
+			// Whitespace before a line break takes it in, so these
+			// lines are a single step.
+			src:      "Stir, then fold.  \n\nAdd @cream{}\n",
+			expected: "Stir, then fold.\nAdd @cream\n",
+		},
+		{
+			src:      "Pour it  \n>> in a glass\n",
+			expected: "Pour it\n >> in a glass\n",
+		},
+	}
+
+	for _, test := range tests {
+		formatted := formatString(t, test.src)
+		if formatted != test.expected {
+			t.Errorf("expected\n%s\ngot\n%s", test.expected, formatted)
+			continue
+		}
+		if again := formatString(t, formatted); again != formatted {
+			t.Errorf("expected formatting to be stable, got\n%s", again)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package cooklang
 
 import (
-	"fmt"
 	"github.com/dememorized/cook/aromalang"
 	"strings"
 	"text/scanner"
@@ -11,9 +10,17 @@ type parser struct {
 	pos    int
 	curr   Token
 	tokens []Token
+	spans  []span
 	Error  error
 }
 
+// span is the range of tokens that a component was read from. A span
+// without a component marks where a new step starts.
+type span struct {
+	comp       aromalang.Component
+	start, end int
+}
+
 func (p *parser) Next() Token {
 	p.curr = p.Peek()
 	if p.curr.Type != TokenEOF {
@@ -32,7 +39,16 @@ func (p *parser) Peek() Token {
 	return p.tokens[p.pos]
 }
 
+// Parse builds the AST of a tokenized Cooklang recipe. Syntax errors
+// are returned as *aromalang.ParseError with their position.
 func Parse(filename string, recipe []Token) (*aromalang.AST, error) {
+	ast, _, err := parse(filename, recipe)
+	return ast, err
+}
+
+// parse is [Parse], but also returns the spans of the components in the
+// order they were read, which the formatter uses to write them back.
+func parse(filename string, recipe []Token) (*aromalang.AST, []span, error) {
 	ast := &aromalang.AST{
 		Filename: filename,
 	}
@@ -56,6 +72,7 @@ func Parse(filename string, recipe []Token) (*aromalang.AST, error) {
 	for p.Error == nil && p.curr.Type != TokenEOF {
 		t := p.curr
 		b := aromalang.Base{Pos: t.Position}
+		start, comps, mds := p.index(), len(step.Components), len(ast.Recipe.Metadata)
 
 		switch t.Type {
 		case TokenNewLine:
@@ -63,11 +80,13 @@ func Parse(filename string, recipe []Token) (*aromalang.AST, error) {
 			if t.Type == TokenNewLine && step.HasInstructions() {
 				ast.Recipe.Steps = append(ast.Recipe.Steps, step)
 				step = aromalang.Step{Base: b}
+				p.spans = append(p.spans, span{start: start, end: start})
 			}
 		case TokenDoubleDash:
+			p.skip(oneOf(TokenDoubleDash))
 			step.Components = append(step.Components, aromalang.Comment{
 				Base:    b,
-				Comment: p.eatUntil(oneOf(TokenNewLine)),
+				Comment: strings.TrimSpace(p.eatUntil(oneOf(TokenNewLine))),
 			})
 		case TokenDoubleGT:
 			if t.Position.Column != 1 {
@@ -76,7 +95,7 @@ func Parse(filename string, recipe []Token) (*aromalang.AST, error) {
 					Instruction: ">>",
 				})
 				p.skip(oneOf(TokenDoubleGT))
-				continue
+				break
 			}
 
 			md := aromalang.Metadata{
@@ -87,7 +106,7 @@ func Parse(filename string, recipe []Token) (*aromalang.AST, error) {
 			md.Key = strings.TrimSpace(p.eatUntil(oneOf(TokenColon, TokenNewLine)))
 
 			if !p.skip(oneOf(TokenColon)) {
-				p.Error = fmt.Errorf("expected colon to separate metadata key and value on line %d", t.Position.Line)
+				p.Error = aromalang.NewErrorf(t.Position, "expected colon to separate metadata key and value")
 			}
 			p.skip(oneOf(TokenWhitespace))
 			md.Value = p.eatUntil(oneOf(TokenNewLine))
@@ -151,21 +170,32 @@ func Parse(filename string, recipe []Token) (*aromalang.AST, error) {
 
 			step.Components = append(step.Components, ing)
 		default:
-			p.Error = fmt.Errorf(
-				"%s: got unknown token %s with value %x",
-				t.Position,
-				t.Type.String(),
-				t.Value,
-			)
+			p.Error = aromalang.NewErrorf(t.Position, "got unknown token %s with value %x", t.Type.String(), t.Value)
 			p.Next()
 		}
+
+		if len(step.Components) > comps {
+			p.spans = append(p.spans, span{comp: step.Components[comps], start: start, end: p.index()})
+		}
+		if len(ast.Recipe.Metadata) > mds {
+			p.spans = append(p.spans, span{comp: ast.Recipe.Metadata[mds], start: start, end: p.index()})
+		}
 	}
 
 	if len(step.Components) != 0 {
 		ast.Recipe.Steps = append(ast.Recipe.Steps, step)
 	}
 
-	return ast, p.Error
+	return ast, p.spans, p.Error
+}
+
+// index returns the position of the current token in the tokens, which
+// is their length once all of them have been read.
+func (p *parser) index() int {
+	if p.curr.Type == TokenEOF {
+		return len(p.tokens)
+	}
+	return p.pos - 1
 }
 
 type condition = func(TokenType) bool
@@ -200,7 +230,7 @@ func (p *parser) rawEatUntil(end condition) []Token {
 		}
 
 		if tok.Type == TokenInvalid || tok.Type == TokenUnknown {
-			p.Error = fmt.Errorf("found invalid token on position: %s", tok.Position)
+			p.Error = aromalang.NewErrorf(tok.Position, "found invalid token")
 			return nil
 		}
 
@@ -0,0 +1,151 @@
+package cooklang
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"strings"
+	"unicode"
+)
+
+// Printer writes recipes as Cooklang. Ingredients and cookware only get
+// braces when they need them to be read back the same, which is when
+// they have a quantity, a name of more than one word or are directly
+// followed by more text. Cooklang has no step metadata, so it is
+// written as comments.
+type Printer struct {
+	// KeepLines keeps the line breaks within steps, and comments on
+	// the line they were on, using the positions of the components.
+	// It is meant for recipes that were parsed from Cooklang.
+	KeepLines bool
+}
+
+// Format writes the recipe as Cooklang with every step on a single
+// line. Comments are written after the step they are in, unless they
+// end it.
+func Format(ast *aromalang.AST) string {
+	return Printer{}.Format(ast)
+}
+
+func (p Printer) Format(ast *aromalang.AST) string {
+	b := strings.Builder{}
+	for _, md := range ast.Recipe.Metadata {
+		b.WriteString(">> " + strings.TrimSpace(md.Key) + ": " + strings.TrimSpace(md.Value) + "\n")
+	}
+
+	for i, step := range ast.Recipe.Steps {
+		if i != 0 || len(ast.Recipe.Metadata) != 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(p.formatStep(step))
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+func (p Printer) formatStep(step aromalang.Step) string {
+	var lines []string
+	line := strings.Builder{}
+	var comments []string
+
+	flush := func() {
+		if l := cleanLine(line.String()); l != "" {
+			lines = append(lines, l)
+		}
+		line.Reset()
+	}
+
+	comps := step.Components
+	for i, c := range comps {
+		if p.KeepLines && i != 0 && c.Position().Line > comps[i-1].Position().Line {
+			flush()
+		}
+
+		switch c := c.(type) {
+		case aromalang.Instruction:
+			line.WriteString(c.Instruction)
+		case aromalang.Ingredient:
+			line.WriteString("@" + c.Name)
+			if c.Quantity != "" || c.Unit != "" || p.needsBraces(c.Name, comps[i+1:], c.Position().Line) {
+				line.WriteString("{" + amount(c.Quantity, c.Unit) + "}")
+			}
+		case aromalang.Cookware:
+			line.WriteString("#" + c.Name)
+			if p.needsBraces(c.Name, comps[i+1:], c.Position().Line) {
+				line.WriteString("{}")
+			}
+		case aromalang.Timer:
+			line.WriteString("~" + c.Name + "{" + amount(c.Magnitude, c.Unit) + "}")
+		case aromalang.Comment:
+			if !p.KeepLines {
+				// A comment at the end of the step stays at the end of
+				// its line, others would swallow the text after them
+				// and are written below the step.
+				if i == len(comps)-1 && i != 0 && len(comments) == 0 && c.Position().Line == comps[i-1].Position().Line {
+					line.WriteString(" -- " + c.Comment)
+					continue
+				}
+				comments = append(comments, c.Comment)
+				continue
+			}
+			// A comment runs until the end of the line.
+			line.WriteString(" -- " + c.Comment)
+			flush()
+		case aromalang.Metadata:
+			comments = append(comments, c.Key+": "+c.Value)
+		}
+	}
+	flush()
+
+	for _, c := range comments {
+		lines = append(lines, "-- "+strings.TrimSpace(c))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func amount(quantity, unit string) string {
+	if unit == "" {
+		return strings.TrimSpace(quantity)
+	}
+	return strings.TrimSpace(quantity) + "%" + strings.TrimSpace(unit)
+}
+
+// needsBraces returns true if an ingredient or cookware called name
+// would be read differently without braces, given the components that
+// follow it in the step.
+func (p Printer) needsBraces(name string, next []aromalang.Component, line int) bool {
+	if name == "" || strings.ContainsAny(name, "@#~%:{}") || strings.IndexFunc(name, unicode.IsSpace) != -1 ||
+		strings.HasPrefix(name, "--") || strings.HasPrefix(name, ">>") {
+		return true
+	}
+
+	for i, c := range next {
+		if p.KeepLines && c.Position().Line > line {
+			return false
+		}
+
+		switch c := c.(type) {
+		case aromalang.Instruction:
+			if i == 0 && c.Instruction != "" && !unicode.IsSpace([]rune(c.Instruction)[0]) {
+				return true
+			}
+			// Without braces, the name would run until the next
+			// brace if it's only separated by text.
+			if strings.Contains(c.Instruction, "{") {
+				return true
+			}
+		case aromalang.Comment, aromalang.Metadata:
+			continue
+		default:
+			// Anything but text directly after the name becomes
+			// part of it.
+			return i == 0
+		}
+	}
+	return false
+}
+
+// cleanLine collapses runs of whitespace and removes it from the ends
+// of the line.
+func cleanLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
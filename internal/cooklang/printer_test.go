@@ -0,0 +1,75 @@
+package cooklang
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseString(t *testing.T, src string) *aromalang.AST {
+	tokens, errs := Tokenize("pancakes.cook", strings.NewReader(src))
+	if len(errs) != 0 {
+		t.Error(errs)
+		t.FailNow()
+	}
+	ast, err := Parse("pancakes.cook", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return ast
+}
+
+func TestFormat(t *testing.T) {
+	ast := parseString(t, pancakes)
+	formatted := Format(ast)
+	again := parseString(t, formatted)
+
+	if len(ast.Recipe.Steps) != len(again.Recipe.Steps) {
+		t.Errorf("expected %d steps, got %d:\n%s", len(ast.Recipe.Steps), len(again.Recipe.Steps), formatted)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(ast.Metadata(), again.Metadata()) {
+		t.Errorf("expected metadata %v, got %v", ast.Metadata(), again.Metadata())
+	}
+	for i := range ast.Recipe.Steps {
+		before, after := ast.Recipe.Steps[i], again.Recipe.Steps[i]
+		if before.Text() != after.Text() {
+			t.Errorf("expected step %q, got %q", before.Text(), after.Text())
+		}
+		for j, ing := range before.Ingredients() {
+			other := after.Ingredients()[j]
+			if ing.Name != other.Name || ing.Quantity != other.Quantity || ing.Unit != other.Unit {
+				t.Errorf("expected %s, got %s", ing, other)
+			}
+		}
+	}
+
+	if !strings.Contains(formatted, "@sea salt{1%pinch}") || !strings.Contains(formatted, "topping. -- Add your favorite topping") {
+		t.Errorf("unexpected format:\n%s", formatted)
+	}
+
+	// A comment in the middle of a step would swallow the rest of it
+	// on a single line, so it is written below together with the ones
+	// after it.
+	formatted = Format(parseString(t, "Add @salt -- to taste\nand stir. -- well\n"))
+	if formatted != "Add @salt and stir.\n-- to taste\n-- well\n" {
+		t.Errorf("expected the comments below the step, got:\n%s", formatted)
+	}
+}
+
+func TestPrinterKeepLines(t *testing.T) {
+	const src = ">> servings: 2\n\nBoil   @water{500%ml} in a #kettle{}.\nAdd @tea{}   and wait ~{3%minutes}.   -- not longer\n\n\n-- serve hot\nServe with @milk{} and @honey{}, or @sugar{1%tsp}\n"
+	const expected = ">> servings: 2\n\nBoil @water{500%ml} in a #kettle{}.\nAdd @tea and wait ~{3%minutes}. -- not longer\n\n-- serve hot\nServe with @milk and @honey{}, or @sugar{1%tsp}\n"
+
+	formatted := Printer{KeepLines: true}.Format(parseString(t, src))
+	if formatted != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, formatted)
+	}
+
+	again := Printer{KeepLines: true}.Format(parseString(t, formatted))
+	if again != formatted {
+		t.Errorf("expected formatting to be stable, got\n%s", again)
+	}
+}
@@ -0,0 +1,35 @@
+package load
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/cooklang"
+	"path"
+	"strings"
+)
+
+// Format rewrites a recipe in the canonical style of its syntax, which
+// is selected by the extension of filename. It works from the tokens of
+// the recipe so that its comments and line breaks are kept, and sorts
+// the metadata with [aromalang.SortMetadata].
+func Format(filename string, src []byte) ([]byte, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ExtCooklang:
+		tokens, errs := cooklang.Tokenize(filename, bytes.NewReader(src))
+		if len(errs) != 0 {
+			return nil, errs[0]
+		}
+		res, err := cooklang.FormatTokens(filename, tokens)
+		return []byte(res), err
+	case ExtAroma:
+		tokens, errs := aromalang.Tokenize(filename, bytes.NewReader(src))
+		if len(errs) != 0 {
+			return nil, errs[0]
+		}
+		res, err := aromalang.FormatTokens(filename, tokens)
+		return []byte(res), err
+	default:
+		return nil, fmt.Errorf("%s: unknown recipe format '%s'", filename, path.Ext(filename))
+	}
+}
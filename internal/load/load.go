@@ -0,0 +1,61 @@
+// Package load parses recipe files in any of the supported syntaxes,
+// selected by file extension.
+package load
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/cooklang"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const (
+	ExtCooklang = ".cook"
+	ExtAroma    = ".aroma"
+)
+
+// Supported returns true if name has the extension of a recipe syntax
+// that can be parsed.
+func Supported(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ExtCooklang, ExtAroma:
+		return true
+	default:
+		return false
+	}
+}
+
+// File parses the recipe called name in fsys.
+func File(fsys fs.FS, name string) (*aromalang.AST, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(name, f)
+}
+
+// Parse parses a recipe using the syntax indicated by the extension of
+// filename.
+func Parse(filename string, recipe io.Reader) (*aromalang.AST, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ExtCooklang:
+		tokens, errs := cooklang.Tokenize(filename, recipe)
+		if len(errs) != 0 {
+			return nil, errs[0]
+		}
+		return cooklang.Parse(filename, tokens)
+	case ExtAroma:
+		tokens, errs := aromalang.Tokenize(filename, recipe)
+		if len(errs) != 0 {
+			return nil, errs[0]
+		}
+		return aromalang.ParseTokens(filename, tokens)
+	default:
+		return nil, fmt.Errorf("%s: unknown recipe format '%s'", filename, path.Ext(filename))
+	}
+}
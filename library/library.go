@@ -0,0 +1,143 @@
+// Package library loads a directory of recipes into memory and finds
+// recipes by their metadata, tags, ingredients, cookware and cooking
+// time.
+package library
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/load"
+	"io/fs"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Recipe is a parsed recipe in the library.
+type Recipe struct {
+	// Path is the slash separated path to the recipe within the
+	// library's file system.
+	Path string
+	AST  *aromalang.AST
+}
+
+// Tags returns the comma separated values of the recipe's "tags"
+// metadata.
+func (r *Recipe) Tags() []string {
+	var tags []string
+	for _, md := range r.AST.Recipe.Metadata {
+		if !strings.EqualFold(strings.TrimSpace(md.Key), "tags") {
+			continue
+		}
+		for _, t := range strings.Split(md.Value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tags = append(tags, t)
+			}
+		}
+	}
+	return tags
+}
+
+// FileError is the reason a file couldn't be loaded into the library.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Library is a set of recipes loaded from a file system.
+type Library struct {
+	// Recipes are sorted by path.
+	Recipes []*Recipe
+	// Errors has an entry for every file that couldn't be read or
+	// parsed, sorted by path. Those files are left out of the library.
+	Errors []*FileError
+}
+
+// Load reads and parses every .cook and .aroma file in fsys using at
+// most workers goroutines at a time, or one per CPU if workers is zero
+// or less. Directories starting with a dot are skipped. An error is
+// only returned if the directory tree couldn't be walked, files that
+// fail to load are listed in the library's Errors.
+func Load(fsys fs.FS, workers int) (*Library, error) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var paths []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != "." && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && load.Supported(p) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	asts := make([]*aromalang.AST, len(paths))
+	errs := make([]error, len(paths))
+
+	work := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers && w < len(paths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				asts[i], errs[i] = load.File(fsys, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	lib := &Library{}
+	for i, p := range paths {
+		if errs[i] != nil {
+			lib.Errors = append(lib.Errors, &FileError{Path: p, Err: errs[i]})
+			continue
+		}
+		lib.Recipes = append(lib.Recipes, &Recipe{Path: p, AST: asts[i]})
+	}
+	return lib, nil
+}
+
+// Get returns the recipe at path.
+func (l *Library) Get(path string) (*Recipe, bool) {
+	i := sort.Search(len(l.Recipes), func(i int) bool {
+		return l.Recipes[i].Path >= path
+	})
+	if i < len(l.Recipes) && l.Recipes[i].Path == path {
+		return l.Recipes[i], true
+	}
+	return nil, false
+}
+
+// Find returns the recipes matching q, sorted by path.
+func (l *Library) Find(q Query) []*Recipe {
+	var res []*Recipe
+	for _, r := range l.Recipes {
+		if q.Match(r) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
@@ -0,0 +1,78 @@
+package library
+
+import (
+	"github.com/dememorized/cook/canonical"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadTestdata(t *testing.T) *Library {
+	lib, err := Load(os.DirFS("testdata"), 2)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return lib
+}
+
+func paths(recipes []*Recipe) string {
+	list := make([]string, 0, len(recipes))
+	for _, r := range recipes {
+		list = append(list, r.Path)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestLoad(t *testing.T) {
+	lib := loadTestdata(t)
+
+	if p := paths(lib.Recipes); p != "desserts/cake.cook pasta.cook salad.aroma" {
+		t.Errorf("expected every recipe but the broken and hidden ones, got %s", p)
+	}
+	if len(lib.Errors) != 1 || lib.Errors[0].Path != "broken.aroma" ||
+		!strings.Contains(lib.Errors[0].Error(), "metadata values must be a string") {
+		t.Errorf("expected an error for the broken recipe, got %v", lib.Errors)
+	}
+
+	r, ok := lib.Get("pasta.cook")
+	if !ok || r.AST.Title() != "Pasta with butter" || strings.Join(r.Tags(), ",") != "Dinner,quick" {
+		t.Errorf("expected to get the pasta, got %v", r)
+	}
+	if _, ok := lib.Get("broken.aroma"); ok {
+		t.Errorf("expected broken recipe to not be in the library")
+	}
+}
+
+func TestFind(t *testing.T) {
+	lib := loadTestdata(t)
+
+	for _, tc := range []struct {
+		name     string
+		query    Query
+		expected string
+	}{
+		{"everything", Query{}, "desserts/cake.cook pasta.cook salad.aroma"},
+		{"metadata", Query{Metadata: map[string]string{"Course": "MAIN"}}, "pasta.cook"},
+		{"metadata key", Query{Metadata: map[string]string{"servings": ""}}, "pasta.cook"},
+		{"tags", Query{Tags: []string{"quick", "dinner"}}, "pasta.cook"},
+		{"ingredient", Query{Ingredients: []string{"Butter"}}, "desserts/cake.cook pasta.cook"},
+		{"ingredient plural", Query{Ingredients: []string{"egg", "sugar"}}, "desserts/cake.cook"},
+		{"excludes", Query{Tags: []string{"quick"}, Excludes: []string{"walnut"}}, "pasta.cook"},
+		{"cookware", Query{Cookware: []string{"pot"}}, "pasta.cook"},
+		{"max time", Query{MaxTime: 15 * time.Minute}, "pasta.cook salad.aroma"},
+		{"max time from prep and cook", Query{MaxTime: 80 * time.Minute}, "desserts/cake.cook pasta.cook salad.aroma"},
+		{"nothing", Query{Ingredients: []string{"chocolate"}}, ""},
+	} {
+		if p := paths(lib.Find(tc.query)); p != tc.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tc.name, tc.expected, p)
+		}
+	}
+
+	names := canonical.NewDictionary()
+	names.Add("pasta", "spaghetti")
+	if p := paths(lib.Find(Query{Ingredients: []string{"pasta"}, Names: names})); p != "pasta.cook" {
+		t.Errorf("expected synonyms to match, got '%s'", p)
+	}
+}
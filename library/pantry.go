@@ -0,0 +1,123 @@
+package library
+
+import (
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/ingredients"
+	"sort"
+	"strings"
+)
+
+// Weights of the ingredients of a recipe when matching it against a
+// pantry. Ingredients without a measured quantity, such as "salt" or
+// "a handful of parsley", are usually garnishes or seasoning that
+// matter less than the ones that are measured.
+const (
+	weightCore    = 1
+	weightGarnish = 0.25
+)
+
+// PantryMatch is how well the ingredients on hand cover a recipe.
+type PantryMatch struct {
+	Recipe *Recipe
+	// Score is the weighted share of the recipe's ingredients that
+	// are on hand, from 0 to 1. Ingredients that are only partly on
+	// hand count for the share of the amount that is.
+	Score float64
+	// Have are the ingredients that are on hand in full.
+	Have []string
+	// Missing are the ingredients that aren't on hand, with the
+	// amounts that have to be bought.
+	Missing []ingredients.Item
+	// MissingCore is the number of measured ingredients in Missing.
+	MissingCore int
+}
+
+// MatchPantry ranks the recipes in the library by how much of their
+// ingredients are in the pantry, best matches first. Ingredient names
+// are compared by their canonical keys with the synonyms in names,
+// which may be nil.
+func (l *Library) MatchPantry(p *ingredients.Pantry, names *canonical.Dictionary) []PantryMatch {
+	matches := make([]PantryMatch, 0, len(l.Recipes))
+	for _, r := range l.Recipes {
+		matches = append(matches, matchPantry(r, p, names))
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.MissingCore != b.MissingCore {
+			return a.MissingCore < b.MissingCore
+		}
+		return strings.ToLower(a.Recipe.AST.Title()) < strings.ToLower(b.Recipe.AST.Title())
+	})
+	return matches
+}
+
+func matchPantry(r *Recipe, p *ingredients.Pantry, names *canonical.Dictionary) PantryMatch {
+	m := PantryMatch{Recipe: r}
+
+	needed := &ingredients.List{Key: names.Key}
+	needed.AddRecipe(r.AST, 1)
+	missing := needed.Subtract(p)
+
+	var total, have float64
+	for _, item := range needed.Items() {
+		weight := float64(weightGarnish)
+		if len(item.Amounts) != 0 {
+			weight = weightCore
+		}
+		total += weight
+
+		rest, ok := missing.Get(item.Name)
+		if !ok {
+			have += weight
+			m.Have = append(m.Have, item.Name)
+			continue
+		}
+
+		m.Missing = append(m.Missing, rest)
+		if len(item.Amounts) != 0 {
+			m.MissingCore++
+			have += weight * covered(item, rest)
+		}
+	}
+
+	if total == 0 {
+		// A recipe without ingredients can always be made.
+		m.Score = 1
+	} else {
+		m.Score = have / total
+	}
+	return m
+}
+
+// covered returns the share of the amounts of needed that aren't in
+// rest, the part of it that is still missing. Amounts that aren't
+// positive are left out.
+func covered(needed, rest ingredients.Item) float64 {
+	share := 0.0
+	n := 0
+	for _, a := range needed.Amounts {
+		if a.Value <= 0 {
+			continue
+		}
+		n++
+		// Amounts that aren't in rest are all on hand.
+		left := 0.0
+		for _, r := range rest.Amounts {
+			if q, err := r.In(a.Unit); err == nil {
+				left = q.Value
+				break
+			}
+		}
+		if left < a.Value {
+			share += 1 - left/a.Value
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return share / float64(n)
+}
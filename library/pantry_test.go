@@ -0,0 +1,57 @@
+package library
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/ingredients"
+	"github.com/dememorized/cook/internal/conversion"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMatchPantry(t *testing.T) {
+	lib := loadTestdata(t)
+	pantry, err := ingredients.LoadPantry(strings.NewReader(`
+spaghetti: 500 g
+butter: 100 g
+parmesan: 10 g
+lettuce
+sugar: 1 kg
+`))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	matches := lib.MatchPantry(pantry, nil)
+	if p := paths([]*Recipe{matches[0].Recipe, matches[1].Recipe, matches[2].Recipe}); p != "salad.aroma desserts/cake.cook pasta.cook" {
+		t.Errorf("expected the recipes with the fewest missing ingredients first, got %s", p)
+	}
+
+	salad := matches[0]
+	if salad.Score != 0.5 || strings.Join(salad.Have, ",") != "lettuce" || len(salad.Missing) != 1 || salad.Missing[0].String() != "walnuts: 50 g" {
+		t.Errorf("expected only walnuts to be missing from the salad, got %+v", salad)
+	}
+
+	cake := matches[1]
+	if cake.Score != 0.5 || cake.MissingCore != 2 || fmt.Sprint(cake.Missing) != "[butter: 100 g eggs: 4]" {
+		t.Errorf("expected half the butter and the eggs to be missing from the cake, got %+v", cake)
+	}
+
+	pasta := matches[2]
+	if math.Abs(pasta.Score-1.25/3) > 1e-9 || fmt.Sprint(pasta.Missing) != "[unsalted butter: 30 g parmesan: 30 g]" {
+		t.Errorf("expected butter and most of the parmesan to be missing from the pasta, got %+v", pasta)
+	}
+}
+
+func TestCovered(t *testing.T) {
+	g, _ := conversion.LookupUnit("g")
+	ml, _ := conversion.LookupUnit("ml")
+	needed := ingredients.Item{Name: "flour", Amounts: []conversion.Quantity{{Value: 0, Unit: ml}, {Value: 200, Unit: g}}}
+	rest := ingredients.Item{Name: "flour", Amounts: []conversion.Quantity{{Value: 100, Unit: g}}}
+
+	// The amount of zero doesn't count, so half of the flour is on hand.
+	if c := covered(needed, rest); c != 0.5 {
+		t.Errorf("expected half of the flour to be covered, got %v", c)
+	}
+}
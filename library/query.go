@@ -0,0 +1,118 @@
+package library
+
+import (
+	"github.com/dememorized/cook/canonical"
+	"strings"
+	"time"
+)
+
+// Query selects recipes from a library. A recipe matches if it matches
+// every condition that is set, and the zero value matches everything.
+type Query struct {
+	// Metadata are values that the recipe's metadata must have for
+	// the keys. Keys and values are compared without regard to case,
+	// and an empty value only requires the key to be present.
+	Metadata map[string]string
+	// Tags must all be in the recipe's tags.
+	Tags []string
+	// Ingredients must all be used by the recipe. A name matches
+	// ingredients with the same canonical name as well as more
+	// specific ones, so that "butter" matches "unsalted butter".
+	Ingredients []string
+	// Excludes are ingredients that the recipe must not use, matched
+	// in the same way as Ingredients.
+	Excludes []string
+	// Cookware must all be used by the recipe, matched in the same way
+	// as Ingredients.
+	Cookware []string
+	// MaxTime is the longest total time of the recipe, see
+	// [aromalang.AST.TotalTime]. Recipes without a known time don't
+	// match if it is set.
+	MaxTime time.Duration
+	// Names are the synonyms used when matching ingredients and
+	// cookware. Optional.
+	Names *canonical.Dictionary
+}
+
+// Match returns true if r matches every condition of the query.
+func (q Query) Match(r *Recipe) bool {
+	md := map[string]string{}
+	for _, m := range r.AST.Recipe.Metadata {
+		md[strings.ToLower(strings.TrimSpace(m.Key))] = strings.TrimSpace(m.Value)
+	}
+	for k, v := range q.Metadata {
+		have, ok := md[strings.ToLower(strings.TrimSpace(k))]
+		if !ok || (v != "" && !strings.EqualFold(have, strings.TrimSpace(v))) {
+			return false
+		}
+	}
+
+	if len(q.Tags) != 0 {
+		tags := map[string]bool{}
+		for _, t := range r.Tags() {
+			tags[strings.ToLower(t)] = true
+		}
+		for _, t := range q.Tags {
+			if !tags[strings.ToLower(strings.TrimSpace(t))] {
+				return false
+			}
+		}
+	}
+
+	if len(q.Ingredients) != 0 || len(q.Excludes) != 0 {
+		var names []string
+		for _, step := range r.AST.Recipe.Steps {
+			for _, ing := range step.Ingredients() {
+				names = append(names, q.Names.Key(ing.Name))
+			}
+		}
+		for _, name := range q.Ingredients {
+			if !q.contains(names, name) {
+				return false
+			}
+		}
+		for _, name := range q.Excludes {
+			if q.contains(names, name) {
+				return false
+			}
+		}
+	}
+
+	if len(q.Cookware) != 0 {
+		var names []string
+		for _, step := range r.AST.Recipe.Steps {
+			for _, c := range step.Cookware() {
+				names = append(names, q.Names.Key(c.Name))
+			}
+		}
+		for _, name := range q.Cookware {
+			if !q.contains(names, name) {
+				return false
+			}
+		}
+	}
+
+	if q.MaxTime > 0 {
+		t, ok := r.AST.TotalTime()
+		if !ok || t.ApproximateDuration() > q.MaxTime {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contains returns true if any of the canonical names in keys is name
+// or a more specific variety of it.
+func (q Query) contains(keys []string, name string) bool {
+	k := q.Names.Key(name)
+	if k == "" {
+		return false
+	}
+	for _, key := range keys {
+		if key == k || strings.Contains(" "+key+" ", " "+k+" ") {
+			return true
+		}
+	}
+	return false
+}
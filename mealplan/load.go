@@ -0,0 +1,128 @@
+package mealplan
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/ingredients"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/internal/load"
+	"io/fs"
+	"path"
+)
+
+// LoadFile parses the meal plan called name in fsys and loads all of
+// its recipes.
+func LoadFile(fsys fs.FS, name string) (*Plan, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	plan, err := Parse(name, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, plan.Load(fsys)
+}
+
+// Load parses every recipe in the plan. Recipe paths are relative to
+// the directory of the plan's filename within fsys. Recipes that are
+// used more than once are only parsed once.
+func (p *Plan) Load(fsys fs.FS) error {
+	dir := path.Dir(p.Filename)
+	loaded := map[string]*aromalang.AST{}
+
+	for d := range p.Days {
+		for m := range p.Days[d].Meals {
+			meal := &p.Days[d].Meals[m]
+			name := path.Join(dir, meal.Path)
+
+			if ast, ok := loaded[name]; ok {
+				meal.Recipe = ast
+				continue
+			}
+
+			ast, err := load.File(fsys, name)
+			if err != nil {
+				return fmt.Errorf("%s: %w", meal.Pos, err)
+			}
+			loaded[name] = ast
+			meal.Recipe = ast
+		}
+	}
+
+	return nil
+}
+
+// Scale is the factor the recipe has to be multiplied with to get the
+// number of servings requested for the meal. It is 1 if either the
+// meal or the recipe doesn't specify the number of servings.
+func (m Meal) Scale() float64 {
+	if m.Servings == 0 || m.Recipe == nil {
+		return 1
+	}
+
+	servings, ok := m.Recipe.Servings()
+	if !ok {
+		return 1
+	}
+	return m.Servings / servings
+}
+
+// Title returns the recipe's title from its metadata, or the path to
+// the recipe if it has none.
+func (m Meal) Title() string {
+	if m.Recipe != nil {
+		if t, ok := m.Recipe.Metadata()["title"]; ok && t != "" {
+			return t
+		}
+	}
+	return m.Path
+}
+
+// TotalTime returns the time it takes to cook the meal, see
+// [aromalang.AST.TotalTime].
+func (m Meal) TotalTime() (conversion.TimeDiff, bool) {
+	if m.Recipe == nil {
+		return conversion.TimeDiff{}, false
+	}
+	return m.Recipe.TotalTime()
+}
+
+// TotalTime returns the sum of the cooking times of every meal of the
+// day. Meals with unknown cooking times are ignored.
+func (d Day) TotalTime() conversion.TimeDiff {
+	var total conversion.TimeDiff
+	for _, m := range d.Meals {
+		if t, ok := m.TotalTime(); ok {
+			total = total.Add(t)
+		}
+	}
+	return total
+}
+
+// Ingredients returns the combined ingredients of every meal of the
+// day.
+func (d Day) Ingredients() *ingredients.List {
+	l := &ingredients.List{}
+	addMeals(l, d.Meals)
+	return l
+}
+
+// Ingredients returns the combined ingredients of every meal in the
+// plan, scaled to the number of servings of each meal.
+func (p *Plan) Ingredients() *ingredients.List {
+	l := &ingredients.List{}
+	addMeals(l, p.Meals())
+	return l
+}
+
+func addMeals(l *ingredients.List, meals []Meal) {
+	for _, m := range meals {
+		if m.Recipe != nil {
+			l.AddRecipe(m.Recipe, m.Scale())
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// Package mealplan reads meal plans, which list the recipes to cook on
+// each day of a period, and combines the referenced recipes into
+// shopping lists and schedules.
+//
+// A meal plan borrows its syntax from Cooklang. Days are started by a
+// header within brackets, and every following line until the next day
+// is the path to a recipe relative to the meal plan, optionally
+// followed by the number of servings within braces:
+//
+//	>> title: Week 42
+//
+//	[Monday]
+//	pancakes.cook{4}
+//	dinner/lasagna.aroma -- leftovers for Tuesday
+//
+//	[Tuesday]
+//	...
+//
+// Metadata lines start with >> and comments with --.
+package mealplan
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"io"
+	"strings"
+	"text/scanner"
+)
+
+type Plan struct {
+	Filename string
+	Metadata []aromalang.Metadata
+	Days     []Day
+}
+
+type Day struct {
+	Pos   scanner.Position
+	Name  string
+	Meals []Meal
+}
+
+type Meal struct {
+	Pos  scanner.Position
+	Path string
+	// Servings is the number of servings to cook, or zero to cook the
+	// recipe as written.
+	Servings float64
+	// Recipe is nil until the plan has been loaded.
+	Recipe *aromalang.AST
+}
+
+// Parse reads a meal plan. It doesn't load the recipes in the plan,
+// see [Plan.Load] for that.
+func Parse(filename string, r io.Reader) (*Plan, error) {
+	plan := &Plan{Filename: filename}
+
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		pos := scanner.Position{Filename: filename, Line: lineNo, Column: 1}
+
+		line := s.Text()
+		if i := strings.Index(line, "--"); i != -1 {
+			line = line[:i]
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		pos.Column += indent
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, ">>"):
+			key, value, ok := strings.Cut(strings.TrimPrefix(line, ">>"), ":")
+			if !ok {
+				return nil, aromalang.NewErrorf(pos, "expected colon to separate metadata key and value")
+			}
+			plan.Metadata = append(plan.Metadata, aromalang.Metadata{
+				Base:  aromalang.Base{Pos: pos},
+				Key:   strings.TrimSpace(key),
+				Value: strings.TrimSpace(value),
+			})
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, aromalang.NewErrorf(pos, "unclosed day header")
+			}
+			plan.Days = append(plan.Days, Day{
+				Pos:  pos,
+				Name: strings.TrimSpace(line[1 : len(line)-1]),
+			})
+		default:
+			if len(plan.Days) == 0 {
+				return nil, aromalang.NewErrorf(pos, "recipe '%s' is not part of a day", line)
+			}
+
+			meal, err := parseMeal(pos, line)
+			if err != nil {
+				return nil, err
+			}
+			day := &plan.Days[len(plan.Days)-1]
+			day.Meals = append(day.Meals, meal)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func parseMeal(pos scanner.Position, line string) (Meal, error) {
+	meal := Meal{Pos: pos, Path: line}
+
+	open := strings.LastIndexByte(line, '{')
+	if open == -1 {
+		return meal, nil
+	}
+	if !strings.HasSuffix(line, "}") {
+		return Meal{}, aromalang.NewErrorf(pos, "unclosed servings")
+	}
+
+	servings, err := conversion.Numeral(strings.TrimSpace(line[open+1 : len(line)-1])).Float()
+	if err != nil || servings <= 0 {
+		return Meal{}, aromalang.NewErrorf(pos, "invalid number of servings '%s'", line[open+1:len(line)-1])
+	}
+
+	meal.Path = strings.TrimSpace(line[:open])
+	meal.Servings = servings
+	return meal, nil
+}
+
+func (p *Plan) MetadataMap() map[string]string {
+	md := map[string]string{}
+	for _, m := range p.Metadata {
+		md[m.Key] = m.Value
+	}
+	return md
+}
+
+// Meals returns every meal in the plan in order.
+func (p *Plan) Meals() []Meal {
+	var meals []Meal
+	for _, d := range p.Days {
+		meals = append(meals, d.Meals...)
+	}
+	return meals
+}
+
+func (p *Plan) String() string {
+	b := strings.Builder{}
+	for _, m := range p.Metadata {
+		b.WriteString(fmt.Sprintf(">> %s: %s\n", m.Key, m.Value))
+	}
+	for i, d := range p.Days {
+		if i != 0 || len(p.Metadata) != 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("[" + d.Name + "]\n")
+		for _, m := range d.Meals {
+			b.WriteString(m.Path)
+			if m.Servings != 0 {
+				b.WriteString("{" + conversion.FormatFloat(m.Servings) + "}")
+			}
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package mealplan
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFile(t *testing.T) {
+	plan, err := LoadFile(os.DirFS("testdata"), "week.plan")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	fmt.Print(plan)
+
+	if len(plan.Days) != 2 || plan.Days[0].Meals[0].Scale() != 2 {
+		t.Errorf("expected two days with the first meal doubled, got %+v", plan.Days)
+	}
+
+	list := plan.Ingredients()
+	for _, item := range list.Items() {
+		fmt.Println(item)
+	}
+
+	milk, ok := list.Get("Milk")
+	if !ok || len(milk.Amounts) != 1 {
+		t.Error("expected milk to be in the list in a single unit")
+		t.FailNow()
+	}
+	if ml := milk.Amounts[0].Value * milk.Amounts[0].Unit.Base; math.Abs(ml-850) > 0.001 {
+		t.Errorf("expected 850 ml milk, got %s", milk.Amounts[0])
+	}
+
+	if d := plan.Days[1].TotalTime(); d.ApproximateDuration() != time.Hour+15*time.Minute {
+		t.Errorf("expected Sunday to take 1h15m, got %v", d)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"pancakes.cook\n":        "testdata/error.plan:1:1: recipe 'pancakes.cook' is not part of a day",
+		"[Monday\n":              "testdata/error.plan:1:1: unclosed day header",
+		"[Monday]\n  soup{many}": "testdata/error.plan:2:3: invalid number of servings 'many'",
+	}
+
+	for src, expected := range tests {
+		_, err := Parse("testdata/error.plan", strings.NewReader(src))
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected error %q, got %v", expected, err)
+		}
+	}
+}
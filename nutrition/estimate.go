@@ -0,0 +1,97 @@
+package nutrition
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+)
+
+// Ingredient is the estimated nutrition of a single ingredient of a
+// recipe.
+type Ingredient struct {
+	Ingredient aromalang.Ingredient
+	Grams      float64
+	Facts      Facts
+}
+
+// Unresolved is an ingredient that couldn't be included in the
+// estimate, together with the reason why.
+type Unresolved struct {
+	Ingredient aromalang.Ingredient
+	Reason     string
+}
+
+// Estimate is the nutritional content of a recipe. Since unresolved
+// ingredients aren't counted, the totals are a lower bound unless
+// Unresolved is empty.
+type Estimate struct {
+	Total       Facts
+	PerServing  Facts
+	Servings    float64
+	Ingredients []Ingredient
+	Unresolved  []Unresolved
+}
+
+// Estimate calculates the nutritional content of every ingredient in
+// the recipe. The per serving values are calculated from the recipe's
+// "servings" metadata, and are equal to the total if the recipe has no
+// servings.
+func (t *Table) Estimate(ast *aromalang.AST) Estimate {
+	est := Estimate{Servings: 1}
+	if servings, ok := ast.Servings(); ok {
+		est.Servings = servings
+	}
+
+	for _, step := range ast.Recipe.Steps {
+		for _, ing := range step.Ingredients() {
+			grams, facts, reason := t.estimateIngredient(ing)
+			if reason != "" {
+				est.Unresolved = append(est.Unresolved, Unresolved{
+					Ingredient: ing,
+					Reason:     reason,
+				})
+				continue
+			}
+
+			est.Ingredients = append(est.Ingredients, Ingredient{
+				Ingredient: ing,
+				Grams:      grams,
+				Facts:      facts,
+			})
+			est.Total = est.Total.Add(facts)
+		}
+	}
+
+	est.PerServing = est.Total.Scale(1 / est.Servings)
+	return est
+}
+
+func (t *Table) estimateIngredient(ing aromalang.Ingredient) (float64, Facts, string) {
+	entry, ok := t.Lookup(ing.Name)
+	if !ok {
+		return 0, Facts{}, "not in nutrition table"
+	}
+	if ing.Quantity == "" {
+		return 0, Facts{}, "no quantity"
+	}
+
+	q, err := conversion.ParseQuantity(ing.Quantity, ing.Unit)
+	if err != nil {
+		return 0, Facts{}, err.Error()
+	}
+
+	grams, ok := q.Grams(entry.Density)
+	if !ok {
+		switch {
+		case q.Unit.Dimension == conversion.DimensionVolume:
+			return 0, Facts{}, "no density for volume unit '" + q.Unit.Symbol + "'"
+		case q.Unit.Symbol == "" && entry.UnitWeight > 0:
+			grams = q.Value * entry.UnitWeight
+		case q.Unit.Symbol == "":
+			return 0, Facts{}, "no unit weight for counted ingredient"
+		default:
+			return 0, Facts{}, "unknown unit '" + q.Unit.Symbol + "'"
+		}
+	}
+
+	return grams, entry.Per100g.Scale(grams / 100), ""
+}
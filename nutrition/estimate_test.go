@@ -0,0 +1,60 @@
+package nutrition
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"math"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/pancakes.aroma
+var pancakes string
+
+//go:embed testdata/nutrition.csv
+var nutritionTable []byte
+
+func TestEstimate(t *testing.T) {
+	table, err := LoadCSV(bytes.NewReader(nutritionTable))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(pancakes))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	est := table.Estimate(ast)
+	fmt.Printf("%+v\n", est.Total)
+
+	// 3 eggs (150 g), 125 g flour and 250 ml milk (257.5 g).
+	expected := 1.5*143 + 1.25*364 + 2.575*61
+	if math.Abs(est.Total.Energy-expected) > 0.001 {
+		t.Errorf("expected %f kcal, got %f", expected, est.Total.Energy)
+	}
+	if est.Servings != 4 || math.Abs(est.PerServing.Energy-expected/4) > 0.001 {
+		t.Errorf("expected 4 servings of %f kcal, got %f servings of %f", expected/4, est.Servings, est.PerServing.Energy)
+	}
+
+	unresolved := map[string]string{}
+	for _, u := range est.Unresolved {
+		unresolved[u.Ingredient.Name] = u.Reason
+	}
+	for _, name := range []string{"sea salt", "butter", "oil"} {
+		if _, ok := unresolved[name]; !ok {
+			t.Errorf("expected %s to be unresolved, got %v", name, unresolved)
+		}
+	}
+}
+
+func TestLoadCSVMissingName(t *testing.T) {
+	_, err := LoadCSV(strings.NewReader("kcal,fat\n100,1\n"))
+	if err == nil {
+		t.Error("expected error for table without name column")
+	}
+}
@@ -0,0 +1,196 @@
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Facts are the nutritional values of an amount of food. Energy is
+// given in kcal and everything else in grams.
+type Facts struct {
+	Energy        float64
+	Fat           float64
+	Carbohydrates float64
+	Sugar         float64
+	Fiber         float64
+	Protein       float64
+	Salt          float64
+}
+
+// Add returns the sum of f and other.
+func (f Facts) Add(other Facts) Facts {
+	return Facts{
+		Energy:        f.Energy + other.Energy,
+		Fat:           f.Fat + other.Fat,
+		Carbohydrates: f.Carbohydrates + other.Carbohydrates,
+		Sugar:         f.Sugar + other.Sugar,
+		Fiber:         f.Fiber + other.Fiber,
+		Protein:       f.Protein + other.Protein,
+		Salt:          f.Salt + other.Salt,
+	}
+}
+
+// Scale multiplies every value of f by factor.
+func (f Facts) Scale(factor float64) Facts {
+	return Facts{
+		Energy:        f.Energy * factor,
+		Fat:           f.Fat * factor,
+		Carbohydrates: f.Carbohydrates * factor,
+		Sugar:         f.Sugar * factor,
+		Fiber:         f.Fiber * factor,
+		Protein:       f.Protein * factor,
+		Salt:          f.Salt * factor,
+	}
+}
+
+// Entry is a row in the nutrition table.
+type Entry struct {
+	Name string
+	// Per100g are the nutritional values of 100 grams of the food.
+	Per100g Facts
+	// Density in grams per millilitre, used to weigh ingredients
+	// measured by volume. Zero if unknown.
+	Density float64
+	// UnitWeight is the weight in grams of one piece of the food,
+	// used for ingredients that are counted rather than measured
+	// (three eggs). Zero if unknown.
+	UnitWeight float64
+}
+
+// Table is a nutrition database keyed by ingredient name.
+type Table struct {
+	entries []Entry
+	index   map[string]int
+	names   *canonical.Dictionary
+}
+
+func NewTable(entries ...Entry) *Table {
+	t := &Table{index: map[string]int{}}
+	for _, e := range entries {
+		t.Add(e)
+	}
+	return t
+}
+
+// Add inserts or replaces an entry in the table.
+func (t *Table) Add(e Entry) {
+	k := t.names.Key(e.Name)
+	if i, ok := t.index[k]; ok {
+		t.entries[i] = e
+		return
+	}
+	t.index[k] = len(t.entries)
+	t.entries = append(t.entries, e)
+}
+
+// UseDictionary matches ingredient names using the synonyms in d.
+func (t *Table) UseDictionary(d *canonical.Dictionary) {
+	entries := t.entries
+	t.entries = nil
+	t.index = map[string]int{}
+	t.names = d
+	for _, e := range entries {
+		t.Add(e)
+	}
+}
+
+// Lookup finds the entry for an ingredient name.
+func (t *Table) Lookup(name string) (Entry, bool) {
+	i, ok := t.index[t.names.Key(name)]
+	if !ok {
+		return Entry{}, false
+	}
+	return t.entries[i], true
+}
+
+func (t *Table) Len() int {
+	return len(t.entries)
+}
+
+// csvColumns maps the accepted column headers to a setter for the
+// column's value.
+var csvColumns = map[string]func(e *Entry, v float64){
+	"energy":        func(e *Entry, v float64) { e.Per100g.Energy = v },
+	"kcal":          func(e *Entry, v float64) { e.Per100g.Energy = v },
+	"calories":      func(e *Entry, v float64) { e.Per100g.Energy = v },
+	"fat":           func(e *Entry, v float64) { e.Per100g.Fat = v },
+	"carbohydrates": func(e *Entry, v float64) { e.Per100g.Carbohydrates = v },
+	"carbs":         func(e *Entry, v float64) { e.Per100g.Carbohydrates = v },
+	"sugar":         func(e *Entry, v float64) { e.Per100g.Sugar = v },
+	"fiber":         func(e *Entry, v float64) { e.Per100g.Fiber = v },
+	"fibre":         func(e *Entry, v float64) { e.Per100g.Fiber = v },
+	"protein":       func(e *Entry, v float64) { e.Per100g.Protein = v },
+	"salt":          func(e *Entry, v float64) { e.Per100g.Salt = v },
+	"density":       func(e *Entry, v float64) { e.Density = v },
+	"unit_weight":   func(e *Entry, v float64) { e.UnitWeight = v },
+}
+
+// LoadCSV reads a nutrition table from CSV. The first row is a header
+// which must contain a "name" column, the other recognized columns are
+// energy (or kcal), fat, carbohydrates, sugar, fiber, protein and salt
+// per 100 grams, density in g/ml and unit_weight in grams. Unknown
+// columns are ignored and empty cells are read as zero.
+func LoadCSV(r io.Reader) (*Table, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("nutrition table is empty")
+		}
+		return nil, err
+	}
+
+	nameCol := -1
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(h))
+		if header[i] == "name" {
+			nameCol = i
+		}
+	}
+	if nameCol == -1 {
+		return nil, fmt.Errorf("nutrition table has no 'name' column")
+	}
+
+	t := NewTable()
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		e := Entry{Name: strings.TrimSpace(row[nameCol])}
+		if e.Name == "" {
+			return nil, fmt.Errorf("line %d: missing name", line)
+		}
+
+		for i, cell := range row {
+			set, ok := csvColumns[header[i]]
+			cell = strings.TrimSpace(cell)
+			if !ok || cell == "" {
+				continue
+			}
+
+			v, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value for %s: %w", line, header[i], err)
+			}
+			set(&e, v)
+		}
+
+		t.Add(e)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,118 @@
+package renderer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CookMode renders a recipe as an interactive HTML document to follow
+// while cooking. It shows one step at a time in large text, turns every
+// timer into a countdown that starts when it's tapped, checks off the
+// ingredients as the steps using them are reached and keeps the screen
+// from going to sleep where the browser supports it.
+type CookMode struct {
+	AST *aromalang.AST
+	// CSS is added after the default style.
+	CSS string
+}
+
+//go:embed cookmode-template.html
+var cookModeTemplateRaw string
+var cookModeTemplate = template.Must(template.New("cookmode-template").Parse(cookModeTemplateRaw))
+
+func init() {
+	Register(Format{
+		Name:        "cook-mode",
+		ContentType: "text/html; charset=utf-8",
+		Extension:   ".html",
+		New: func(ast *aromalang.AST) Renderer {
+			return CookMode{AST: ast}
+		},
+	})
+}
+
+type cookModeData struct {
+	Title       string
+	Lang        string
+	Labels      map[string]string
+	Ingredients []listItem
+	Steps       []cookModeStep
+	CSS         template.CSS
+}
+
+type cookModeStep struct {
+	htmlStep
+	Number int
+	// Ingredients are the indexes in the ingredient list of the
+	// ingredients used by the step, separated by spaces.
+	Ingredients string
+}
+
+func (c CookMode) Render() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := c.RenderTo(&buf, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c CookMode) RenderTo(w io.Writer, opts Options) error {
+	if c.AST == nil {
+		return fmt.Errorf("no recipe provided")
+	}
+	ast := opts.Apply(c.AST)
+
+	steps, err := renderSteps(ast.Recipe.Steps, htmlRenderComponent)
+	if err != nil {
+		return err
+	}
+
+	data := cookModeData{
+		Title:       ast.Title(),
+		Lang:        opts.language(),
+		Ingredients: opts.ingredientList(c.AST),
+		Labels:      labels["en"],
+		CSS:         template.CSS(c.CSS),
+	}
+	if data.Lang == "" {
+		data.Lang = "en"
+	}
+	if l, ok := labels[data.Lang]; ok {
+		data.Labels = l
+	}
+
+	index := map[string]int{}
+	for i, ing := range data.Ingredients {
+		index[aromalang.Ingredient{Name: ing.Name}.Key()] = i
+	}
+
+	for i, comps := range steps {
+		if len(comps) == 0 {
+			continue
+		}
+		step := ast.Recipe.Steps[i]
+
+		var used []string
+		for _, ing := range step.Ingredients() {
+			if j, ok := index[ing.Key()]; ok {
+				used = append(used, strconv.Itoa(j))
+			}
+		}
+
+		data.Steps = append(data.Steps, cookModeStep{
+			htmlStep:    newHTMLStep(step, comps),
+			Number:      len(data.Steps) + 1,
+			Ingredients: strings.Join(used, " "),
+		})
+	}
+
+	return cookModeTemplate.Execute(w, data)
+}
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"github.com/dememorized/cook/aromalang"
 	"html/template"
-	"reflect"
+	"io"
 	"strings"
 )
 
 type HTML struct {
 	AST *aromalang.AST
+	// JSONLD embeds the recipe as schema.org JSON-LD, see [JSONLD].
+	JSONLD bool
 }
 
 //go:embed html-template.html
@@ -19,38 +21,63 @@ var htmlTemplateRaw string
 var htmlTemplate = template.Must(template.New("html-template").Parse(htmlTemplateRaw))
 
 type htmlData struct {
-	Steps []htmlStep
+	JSONLD template.HTML
+	Steps  []htmlStep
 }
 
 type htmlStep struct {
+	// Attributes holds the step's metadata as data attributes.
+	Attributes template.HTMLAttr
 	Components []any
 }
 
-func (h HTML) Render() ([]byte, error) {
-	if h.AST == nil {
-		return nil, fmt.Errorf("no recipe provided")
+func newHTMLStep(step aromalang.Step, comps []any) htmlStep {
+	attrs := strings.Builder{}
+	for _, md := range step.Metadata() {
+		name := htmlDataAttributeName(md.Key)
+		if name == "" {
+			continue
+		}
+		attrs.WriteString(fmt.Sprintf(` data-%s="%s"`, name, template.HTMLEscapeString(md.Value)))
 	}
 
-	data := htmlData{}
-
-	for _, step := range h.AST.Recipe.Steps {
-		comps := []any{}
-		for _, c := range step.Components {
-			r, err := htmlRenderComponent(c)
-			if err != nil {
-				return nil, err
-			}
+	return htmlStep{
+		Attributes: template.HTMLAttr(attrs.String()),
+		Components: comps,
+	}
+}
 
-			comps = append(comps, r)
+// htmlDataAttributeName turns a metadata key into a valid name for a
+// data attribute by lower casing it and replacing everything but
+// letters and digits with dashes.
+func htmlDataAttributeName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
 		}
-		data.Steps = append(
-			data.Steps,
-			htmlStep{Components: comps},
-		)
-	}
+	}, key)
+	return strings.Trim(name, "-")
+}
+
+func init() {
+	Register(Format{
+		Name:        "html",
+		ContentType: "text/html; charset=utf-8",
+		Extension:   ".html",
+		New: func(ast *aromalang.AST) Renderer {
+			return HTML{AST: ast}
+		},
+	})
+}
 
+func (h HTML) Render() ([]byte, error) {
 	buf := bytes.Buffer{}
-	err := htmlTemplate.Execute(&buf, data)
+	err := h.RenderTo(&buf, Options{})
 	if err != nil {
 		return nil, err
 	}
@@ -58,19 +85,59 @@ func (h HTML) Render() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func htmlRenderComponent(component aromalang.Component) (any, error) {
+func (h HTML) RenderTo(w io.Writer, opts Options) error {
+	if h.AST == nil {
+		return fmt.Errorf("no recipe provided")
+	}
+
+	ast := opts.Apply(h.AST)
+	steps, err := renderSteps(ast.Recipe.Steps, htmlRenderComponent)
+	if err != nil {
+		return err
+	}
+
+	data := htmlData{}
+	for i, comps := range steps {
+		data.Steps = append(data.Steps, newHTMLStep(ast.Recipe.Steps[i], comps))
+	}
+
+	if h.JSONLD {
+		data.JSONLD, err = JSONLD{AST: h.AST}.Script(opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	return htmlTemplate.Execute(w, data)
+}
+
+func htmlRenderComponent(component aromalang.Component) (any, bool, error) {
+	var (
+		r   any
+		err error
+	)
+
 	switch c := component.(type) {
 	case aromalang.Instruction:
-		return c.Instruction, nil
+		r = c.Instruction
 	case aromalang.Ingredient:
-		return htmlRenderIngredient(c)
+		r, err = htmlRenderIngredient(c)
 	case aromalang.Cookware:
-		return htmlRenderCookware(c)
+		r, err = htmlRenderCookware(c)
 	case aromalang.Timer:
-		return htmlRenderTimer(c)
+		r, err = htmlRenderTimer(c)
+	case aromalang.Comment:
+		r, err = htmlRenderComment(c)
+	case aromalang.Metadata:
+		// Step metadata is rendered as attributes on the step.
+		return nil, true, nil
 	default:
-		return nil, fmt.Errorf("cannot render component of type %s", reflect.TypeOf(component))
+		// Leave out components that this version of the renderer
+		// doesn't know about, rather than failing the whole recipe.
+		return nil, true, nil
 	}
+
+	return r, false, err
 }
 
 var (
@@ -78,11 +145,15 @@ var (
 		`<span class="cook-ingredient">{{ if .Quantity }}{{ .Quantity }} {{ end }}{{ if .Unit }}{{ .Unit }} {{ end}}{{ .Name }}</span>`,
 	))
 	htmlTemplateTimer = template.Must(template.New("html-timer").Parse(
-		`<span class="cook-timer" alt="{{ .Name }}">{{ .Magnitude }} {{ .Unit }}</span>`,
+		`{{ if .Seconds }}<time class="cook-timer" datetime="{{ .Duration }}" data-seconds="{{ .Seconds }}"{{ with .Name }} data-name="{{ . }}" title="{{ . }}"{{ end }}>{{ .Magnitude }} {{ .Unit }}</time>` +
+			`{{ else }}<span class="cook-timer"{{ with .Name }} data-name="{{ . }}" title="{{ . }}"{{ end }}>{{ .Magnitude }} {{ .Unit }}</span>{{ end }}`,
 	))
 	htmlTemplateCookware = template.Must(template.New("html-timer").Parse(
 		`<span class="cook-cookware">{{ .Name }}</span>`,
 	))
+	htmlTemplateComment = template.Must(template.New("html-comment").Parse(
+		`<span class="cook-comment">{{ . }}</span>`,
+	))
 )
 
 func htmlRenderIngredient(ingredient aromalang.Ingredient) (template.HTML, error) {
@@ -94,9 +165,23 @@ func htmlRenderIngredient(ingredient aromalang.Ingredient) (template.HTML, error
 	return template.HTML(buf.String()), nil
 }
 
+// htmlTimer is a timer with its duration in the forms used by the
+// <time> element and by scripts that count down the timer.
+type htmlTimer struct {
+	aromalang.Timer
+	Duration string
+	Seconds  int64
+}
+
 func htmlRenderTimer(timer aromalang.Timer) (template.HTML, error) {
+	t := htmlTimer{Timer: timer}
+	if d, err := timer.Duration(); err == nil && !d.IsZero() {
+		t.Duration = d.ISO8601()
+		t.Seconds = int64(d.ApproximateDuration().Seconds())
+	}
+
 	buf := &strings.Builder{}
-	err := htmlTemplateTimer.Execute(buf, timer)
+	err := htmlTemplateTimer.Execute(buf, t)
 	if err != nil {
 		return "", err
 	}
@@ -111,3 +196,12 @@ func htmlRenderCookware(cookware aromalang.Cookware) (template.HTML, error) {
 	}
 	return template.HTML(buf.String()), nil
 }
+
+func htmlRenderComment(comment aromalang.Comment) (template.HTML, error) {
+	buf := &strings.Builder{}
+	err := htmlTemplateComment.Execute(buf, strings.TrimSpace(comment.Comment))
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"fmt"
 	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/cooklang"
 	"strings"
 	"testing"
 )
@@ -26,3 +27,79 @@ func TestGenerateHTML(t *testing.T) {
 
 	fmt.Println(string(b))
 }
+
+//go:embed testdata/pancakes.cook
+var sampleCooklang string
+
+func TestGenerateHTMLWithComments(t *testing.T) {
+	tokens, errs := cooklang.Tokenize("pancakes.cook", strings.NewReader(sampleCooklang))
+	if len(errs) != 0 {
+		t.Error(errs)
+		t.FailNow()
+	}
+	res, err := cooklang.Parse("pancakes.cook", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := HTML{AST: res}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	expected := `<span class="cook-comment">Add your favorite topping here to make sure it&#39;s included in your meal plan!</span>`
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected comment to be rendered, got %s", b)
+	}
+}
+
+func TestGenerateHTMLStepMetadata(t *testing.T) {
+	res, err := aromalang.Parse("steps.aroma", strings.NewReader(
+		`(recipe {} [(step {"Prep Time" "5 min" "oven" "220 \"C\""} [(instruction "Bake.")])])`,
+	))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := HTML{AST: res}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	expected := `<p data-prep-time="5 min" data-oven="220 &#34;C&#34;">Bake.</p>`
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected step metadata as data attributes, got %s", b)
+	}
+}
+
+func TestGenerateHTMLTimer(t *testing.T) {
+	res, err := aromalang.Parse("timer.aroma", strings.NewReader(
+		`(recipe {} [(step {} [(instruction "Boil for ") (timer "eggs" {:magnitude "1.5" :unit "minutes"}) (timer "" {:magnitude "a while" :unit ""})])])`,
+	))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := HTML{AST: res}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	for _, expected := range []string{
+		`<time class="cook-timer" datetime="PT1M30S" data-seconds="90" data-name="eggs" title="eggs">1.5 minutes</time>`,
+		`<span class="cook-timer">a while </span>`,
+	} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("expected %s, got %s", expected, b)
+		}
+	}
+	if strings.Contains(string(b), "alt=") {
+		t.Errorf("expected no alt attributes, got %s", b)
+	}
+}
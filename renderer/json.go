@@ -0,0 +1,139 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"io"
+)
+
+// JSON renders a recipe in the same structure as the results of the
+// Cooklang canonical tests, with numeric quantities as numbers:
+//
+//	{"metadata": {"servings": "2"}, "steps": [[{"type": "text", "value": "Add "}, ...]]}
+//
+// Ingredients, cookware and the title are also listed separately for
+// consumers that don't want to walk the steps.
+type JSON struct {
+	AST *aromalang.AST
+	// Indent is used to pretty-print the output when it's not empty.
+	Indent string
+}
+
+func init() {
+	Register(Format{
+		Name:        "json",
+		ContentType: "application/json",
+		Extension:   ".json",
+		New: func(ast *aromalang.AST) Renderer {
+			return JSON{AST: ast, Indent: "  "}
+		},
+	})
+}
+
+type jsonRecipe struct {
+	Title       string            `json:"title"`
+	Metadata    map[string]string `json:"metadata"`
+	Ingredients []jsonComponent   `json:"ingredients"`
+	Cookware    []jsonComponent   `json:"cookware"`
+	Steps       [][]jsonComponent `json:"steps"`
+}
+
+type jsonComponent struct {
+	Type     string `json:"type"`
+	Value    string `json:"value,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Quantity any    `json:"quantity,omitempty"`
+	Units    string `json:"units,omitempty"`
+}
+
+func (j JSON) Render() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := j.RenderTo(&buf, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (j JSON) RenderTo(w io.Writer, opts Options) error {
+	if j.AST == nil {
+		return fmt.Errorf("no recipe provided")
+	}
+	// JSON is meant for machines, so numbers are never localized.
+	opts.Locale = ""
+	ast := opts.Apply(j.AST)
+
+	data := jsonRecipe{
+		Title:       ast.Title(),
+		Metadata:    ast.Metadata(),
+		Ingredients: []jsonComponent{},
+		Cookware:    []jsonComponent{},
+	}
+
+	steps, err := renderSteps(ast.Recipe.Steps, jsonRenderComponent)
+	if err != nil {
+		return err
+	}
+	data.Steps = steps
+
+	for _, comps := range steps {
+		for _, c := range comps {
+			switch c.Type {
+			case "ingredient":
+				data.Ingredients = append(data.Ingredients, c)
+			case "cookware":
+				data.Cookware = append(data.Cookware, c)
+			}
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", j.Indent)
+	return enc.Encode(data)
+}
+
+func jsonRenderComponent(component aromalang.Component) (jsonComponent, bool, error) {
+	switch c := component.(type) {
+	case aromalang.Instruction:
+		return jsonComponent{Type: "text", Value: c.Instruction}, false, nil
+	case aromalang.Ingredient:
+		return jsonComponent{
+			Type:     "ingredient",
+			Name:     c.Name,
+			Quantity: jsonQuantity(c.Quantity),
+			Units:    c.Unit,
+		}, false, nil
+	case aromalang.Cookware:
+		return jsonComponent{Type: "cookware", Name: c.Name}, false, nil
+	case aromalang.Timer:
+		return jsonComponent{
+			Type:     "timer",
+			Name:     c.Name,
+			Quantity: jsonQuantity(c.Magnitude),
+			Units:    c.Unit,
+		}, false, nil
+	case aromalang.Comment:
+		return jsonComponent{Type: "comment", Value: c.Comment}, false, nil
+	case aromalang.Metadata:
+		// Step metadata has no counterpart in Cooklang.
+		return jsonComponent{}, true, nil
+	default:
+		// Leave out components that this version of the renderer
+		// doesn't know about, rather than failing the whole recipe.
+		return jsonComponent{}, true, nil
+	}
+}
+
+func jsonQuantity(q string) any {
+	if q == "" {
+		return nil
+	}
+	if f, err := conversion.Numeral(q).Float(); err == nil {
+		return f
+	}
+	return q
+}
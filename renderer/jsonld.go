@@ -0,0 +1,159 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// JSONLD renders a recipe as a schema.org Recipe in JSON-LD, which is
+// what search engines read to show recipes as rich results.
+type JSONLD struct {
+	AST *aromalang.AST
+	// Indent is used to pretty-print the output when it's not empty.
+	Indent string
+}
+
+func init() {
+	Register(Format{
+		Name:        "jsonld",
+		ContentType: "application/ld+json",
+		Extension:   ".jsonld",
+		New: func(ast *aromalang.AST) Renderer {
+			return JSONLD{AST: ast, Indent: "  "}
+		},
+	})
+}
+
+type schemaRecipe struct {
+	Context            string        `json:"@context"`
+	Type               string        `json:"@type"`
+	Name               string        `json:"name"`
+	Description        string        `json:"description,omitempty"`
+	Author             *schemaThing  `json:"author,omitempty"`
+	URL                string        `json:"url,omitempty"`
+	Image              string        `json:"image,omitempty"`
+	Keywords           string        `json:"keywords,omitempty"`
+	RecipeCategory     string        `json:"recipeCategory,omitempty"`
+	RecipeCuisine      string        `json:"recipeCuisine,omitempty"`
+	RecipeYield        string        `json:"recipeYield,omitempty"`
+	PrepTime           string        `json:"prepTime,omitempty"`
+	CookTime           string        `json:"cookTime,omitempty"`
+	TotalTime          string        `json:"totalTime,omitempty"`
+	RecipeIngredient   []string      `json:"recipeIngredient"`
+	Tool               []schemaThing `json:"tool,omitempty"`
+	RecipeInstructions []schemaThing `json:"recipeInstructions"`
+}
+
+type schemaThing struct {
+	Type     string `json:"@type"`
+	Name     string `json:"name,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Position int    `json:"position,omitempty"`
+}
+
+func (j JSONLD) Render() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := j.RenderTo(&buf, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (j JSONLD) RenderTo(w io.Writer, opts Options) error {
+	if j.AST == nil {
+		return fmt.Errorf("no recipe provided")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", j.Indent)
+	return enc.Encode(j.schema(opts))
+}
+
+// Script returns the JSON-LD within a script tag, for embedding in an
+// HTML document.
+func (j JSONLD) Script(opts Options) (template.HTML, error) {
+	buf := bytes.Buffer{}
+	buf.WriteString(`<script type="application/ld+json">`)
+	// The encoder escapes <, > and & so the JSON can't end the
+	// script early.
+	if err := j.RenderTo(&buf, opts); err != nil {
+		return "", err
+	}
+	buf.WriteString(`</script>`)
+	return template.HTML(buf.String()), nil
+}
+
+func (j JSONLD) schema(opts Options) schemaRecipe {
+	ast := opts.Apply(j.AST)
+
+	md := map[string]string{}
+	for k, v := range ast.Metadata() {
+		md[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+
+	r := schemaRecipe{
+		Context:          "https://schema.org",
+		Type:             "Recipe",
+		Name:             ast.Title(),
+		Description:      md["description"],
+		URL:              firstOf(md, "source", "url", "source.url"),
+		Image:            firstOf(md, "image", "picture"),
+		Keywords:         firstOf(md, "tags", "keywords"),
+		RecipeCategory:   firstOf(md, "category", "course"),
+		RecipeCuisine:    md["cuisine"],
+		RecipeYield:      firstOf(md, "servings", "yield"),
+		RecipeIngredient: []string{},
+	}
+
+	if author := firstOf(md, "author", "source.author"); author != "" {
+		r.Author = &schemaThing{Type: "Person", Name: author}
+	}
+
+	if d, ok := ast.MetadataDuration(aromalang.PrepTimeKeys...); ok {
+		r.PrepTime = d.ISO8601()
+	}
+	if d, ok := ast.MetadataDuration(aromalang.CookTimeKeys...); ok {
+		r.CookTime = d.ISO8601()
+	}
+	if d, ok := ast.TotalTime(); ok {
+		r.TotalTime = d.ISO8601()
+	}
+
+	for _, item := range opts.ingredientList(j.AST) {
+		r.RecipeIngredient = append(r.RecipeIngredient, strings.TrimSpace(item.Quantity+" "+item.Name))
+	}
+	for _, name := range cookwareList(ast) {
+		r.Tool = append(r.Tool, schemaThing{Type: "HowToTool", Name: name})
+	}
+
+	r.RecipeInstructions = []schemaThing{}
+	for _, step := range ast.Recipe.Steps {
+		text := step.Text()
+		if text == "" {
+			continue
+		}
+		r.RecipeInstructions = append(r.RecipeInstructions, schemaThing{
+			Type:     "HowToStep",
+			Text:     text,
+			Position: len(r.RecipeInstructions) + 1,
+		})
+	}
+
+	return r
+}
+
+func firstOf(md map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := md[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
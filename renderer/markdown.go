@@ -0,0 +1,157 @@
+package renderer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"io"
+	"strings"
+)
+
+// Markdown renders a recipe as a CommonMark document with a metadata
+// table, lists of ingredients and cookware, and numbered steps.
+type Markdown struct {
+	AST *aromalang.AST
+}
+
+func init() {
+	Register(Format{
+		Name:        "markdown",
+		ContentType: "text/markdown; charset=utf-8",
+		Extension:   ".md",
+		New: func(ast *aromalang.AST) Renderer {
+			return Markdown{AST: ast}
+		},
+	})
+}
+
+func (m Markdown) Render() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := m.RenderTo(&buf, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (m Markdown) RenderTo(w io.Writer, opts Options) error {
+	if m.AST == nil {
+		return fmt.Errorf("no recipe provided")
+	}
+	ast := opts.Apply(m.AST)
+
+	steps, err := renderSteps(ast.Recipe.Steps, markdownRenderComponent)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n", markdownEscape(ast.Title()))
+
+	if md := displayMetadata(ast); len(md) != 0 {
+		bw.WriteString("\n| | |\n| --- | --- |\n")
+		for _, m := range md {
+			fmt.Fprintf(bw, "| %s | %s |\n", markdownCell(m.Key), markdownCell(m.Value))
+		}
+	}
+
+	if list := opts.ingredientList(m.AST); len(list) != 0 {
+		fmt.Fprintf(bw, "\n## %s\n\n", opts.label("ingredients"))
+		for _, item := range list {
+			if item.Quantity == "" {
+				fmt.Fprintf(bw, "- %s\n", markdownEscape(item.Name))
+			} else {
+				fmt.Fprintf(bw, "- %s %s\n", markdownEscape(item.Quantity), markdownEscape(item.Name))
+			}
+		}
+	}
+
+	if list := cookwareList(ast); len(list) != 0 {
+		fmt.Fprintf(bw, "\n## %s\n\n", opts.label("cookware"))
+		for _, name := range list {
+			fmt.Fprintf(bw, "- %s\n", markdownEscape(name))
+		}
+	}
+
+	fmt.Fprintf(bw, "\n## %s\n", opts.label("steps"))
+	n := 0
+	for _, comps := range steps {
+		text := strings.Builder{}
+		var comments []string
+		for _, c := range comps {
+			if c.comment {
+				comments = append(comments, c.text)
+				continue
+			}
+			text.WriteString(c.text)
+		}
+
+		if strings.TrimSpace(text.String()) == "" && len(comments) == 0 {
+			continue
+		}
+
+		n++
+		prefix := fmt.Sprintf("%d. ", n)
+		indent := strings.Repeat(" ", len(prefix))
+		if t := strings.TrimSpace(text.String()); t != "" {
+			fmt.Fprintf(bw, "\n%s%s\n", prefix, t)
+		} else {
+			fmt.Fprintf(bw, "\n%s\n", strings.TrimSpace(prefix))
+		}
+		for _, c := range comments {
+			fmt.Fprintf(bw, "\n%s> %s\n", indent, c)
+		}
+	}
+
+	return bw.Flush()
+}
+
+type markdownComponent struct {
+	text    string
+	comment bool
+}
+
+func markdownRenderComponent(component aromalang.Component) (markdownComponent, bool, error) {
+	switch c := component.(type) {
+	case aromalang.Instruction:
+		return markdownComponent{text: markdownEscape(c.Instruction)}, false, nil
+	case aromalang.Ingredient:
+		return markdownComponent{text: "**" + markdownEscape(strings.TrimSpace(c.Name)) + "**"}, false, nil
+	case aromalang.Cookware:
+		return markdownComponent{text: markdownEscape(c.Name)}, false, nil
+	case aromalang.Timer:
+		return markdownComponent{text: "*" + markdownEscape(strings.TrimSpace(c.Magnitude+" "+c.Unit)) + "*"}, false, nil
+	case aromalang.Comment:
+		return markdownComponent{text: markdownEscape(strings.TrimSpace(c.Comment)), comment: true}, false, nil
+	case aromalang.Metadata:
+		return markdownComponent{}, true, nil
+	default:
+		// Leave out components that this version of the renderer
+		// doesn't know about, rather than failing the whole recipe.
+		return markdownComponent{}, true, nil
+	}
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	"#", `\#`,
+	"\n", " ",
+)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownCell escapes text for use in a table cell, where pipes end
+// the cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(markdownEscape(s), "|", `\|`)
+}
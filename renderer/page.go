@@ -0,0 +1,200 @@
+package renderer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"strings"
+)
+
+// Page renders a recipe as a standalone HTML document with a metadata
+// header, lists of the ingredients and cookware needed, numbered steps
+// and embedded CSS that works both on screen and in print.
+//
+// The document is built from the templates "page", "header",
+// "ingredients", "steps" and "style". Any of them can be replaced by
+// defining a template with the same name in the *.html files of
+// Templates, so a theme may consist of only a new "style".
+type Page struct {
+	AST       *aromalang.AST
+	Templates fs.FS
+	// CSS is added after the theme's style.
+	CSS string
+	// JSONLD embeds the recipe as schema.org JSON-LD, see [JSONLD].
+	JSONLD bool
+	// Microdata annotates the document with schema.org Recipe
+	// microdata.
+	Microdata bool
+}
+
+//go:embed page-template.html
+var pageTemplateRaw string
+var pageTemplate = template.Must(template.New("page-template").Parse(pageTemplateRaw))
+
+func init() {
+	Register(Format{
+		Name:        "page",
+		ContentType: "text/html; charset=utf-8",
+		Extension:   ".html",
+		New: func(ast *aromalang.AST) Renderer {
+			return Page{AST: ast}
+		},
+	})
+}
+
+type pageData struct {
+	Title       string
+	Lang        string
+	Metadata    []pageMetadata
+	Ingredients []listItem
+	Cookware    []string
+	Steps       []htmlStep
+	Labels      map[string]string
+	CSS         template.CSS
+	Head        template.HTML
+	Microdata   bool
+}
+
+type pageMetadata struct {
+	Key   string
+	Label string
+	Class string
+	Value string
+	Link  string
+	// Itemprop is the schema.org property of the metadata, if any.
+	Itemprop string
+}
+
+// pageItemprops maps metadata keys to schema.org Recipe properties.
+var pageItemprops = map[string]string{
+	"author":      "author",
+	"servings":    "recipeYield",
+	"yield":       "recipeYield",
+	"description": "description",
+	"cuisine":     "recipeCuisine",
+	"category":    "recipeCategory",
+	"course":      "recipeCategory",
+	"tags":        "keywords",
+	"keywords":    "keywords",
+	"source":      "url",
+}
+
+func (p Page) Render() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := p.RenderTo(&buf, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (p Page) RenderTo(w io.Writer, opts Options) error {
+	tmpl, err := p.template()
+	if err != nil {
+		return err
+	}
+
+	data, err := p.data(opts)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.ExecuteTemplate(w, "page", data)
+}
+
+func (p Page) template() (*template.Template, error) {
+	if p.Templates == nil {
+		return pageTemplate, nil
+	}
+
+	// Templates can't be cloned after they've been executed, so the
+	// user's templates are parsed on top of a fresh default template.
+	tmpl, err := template.New("page-template").Parse(pageTemplateRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := fs.Glob(p.Templates, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return tmpl, nil
+	}
+
+	tmpl, err = tmpl.ParseFS(p.Templates, matches...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse user templates: %w", err)
+	}
+	return tmpl, nil
+}
+
+func (p Page) data(opts Options) (pageData, error) {
+	if p.AST == nil {
+		return pageData{}, fmt.Errorf("no recipe provided")
+	}
+	ast := opts.Apply(p.AST)
+
+	steps, err := renderSteps(ast.Recipe.Steps, htmlRenderComponent)
+	if err != nil {
+		return pageData{}, err
+	}
+
+	data := pageData{
+		Title:       ast.Title(),
+		Lang:        opts.language(),
+		Ingredients: opts.ingredientList(p.AST),
+		Cookware:    cookwareList(ast),
+		Labels:      labels["en"],
+		CSS:         template.CSS(p.CSS),
+		Microdata:   p.Microdata,
+	}
+	if data.Lang == "" {
+		data.Lang = "en"
+	}
+	if l, ok := labels[data.Lang]; ok {
+		data.Labels = l
+	}
+
+	for i, comps := range steps {
+		if len(comps) == 0 {
+			continue
+		}
+		data.Steps = append(data.Steps, newHTMLStep(ast.Recipe.Steps[i], comps))
+	}
+
+	if p.JSONLD {
+		data.Head, err = JSONLD{AST: p.AST}.Script(opts)
+		if err != nil {
+			return pageData{}, err
+		}
+	}
+
+	for _, md := range displayMetadata(ast) {
+		key := strings.ToLower(md.Key)
+		m := pageMetadata{
+			Key:   md.Key,
+			Label: md.Key,
+			Class: strings.Join(strings.Fields(key), "-"),
+			Value: md.Value,
+		}
+		if l, ok := data.Labels[key]; ok {
+			m.Label = l
+		}
+		if p.Microdata {
+			m.Itemprop = pageItemprops[key]
+		}
+		if u, err := url.Parse(md.Value); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			m.Link = md.Value
+		}
+		data.Metadata = append(data.Metadata, m)
+	}
+
+	return data, nil
+}
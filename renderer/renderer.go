@@ -0,0 +1,199 @@
+package renderer
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Renderer writes a recipe in some output format.
+type Renderer interface {
+	RenderTo(w io.Writer, opts Options) error
+}
+
+// Options changes how a recipe is rendered. The zero value renders the
+// recipe as written.
+type Options struct {
+	// Scale multiplies every ingredient quantity and the number of
+	// servings. Zero is treated as 1.
+	Scale float64
+	// Units converts every ingredient quantity to the given unit
+	// system.
+	Units conversion.System
+	// Locale is the language used for headings and number formatting,
+	// such as "en" or "sv". Defaults to English.
+	Locale string
+}
+
+// Factory creates a Renderer for a recipe.
+type Factory func(ast *aromalang.AST) Renderer
+
+// Format is an output format that can be selected by name.
+type Format struct {
+	Name        string
+	ContentType string
+	// Extension is the file extension, including the leading dot,
+	// for files in this format.
+	Extension string
+	New       Factory
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{}
+)
+
+// Register makes an output format available by its name. It panics if
+// a format with the same name has already been registered.
+func Register(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if f.New == nil {
+		panic("renderer: Register factory is nil for format " + f.Name)
+	}
+	if _, exists := formats[f.Name]; exists {
+		panic("renderer: Register called twice for format " + f.Name)
+	}
+	formats[f.Name] = f
+}
+
+// Lookup returns the format registered as name.
+func Lookup(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Formats returns the names of every registered format, sorted.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns a renderer for ast in the format registered as name.
+func New(name string, ast *aromalang.AST) (Renderer, error) {
+	f, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown format '%s', expected one of %s", name, strings.Join(Formats(), ", "))
+	}
+	return f.New(ast), nil
+}
+
+// Apply returns a copy of the recipe with the options' scale, unit
+// system and number formatting applied to its ingredients. Quantities
+// that aren't numeric are left as they are.
+func (o Options) Apply(ast *aromalang.AST) *aromalang.AST {
+	if o.Scale == 0 {
+		o.Scale = 1
+	}
+	if o.Scale == 1 && o.Units == conversion.SystemAny && o.decimalSeparator() == "." {
+		return ast
+	}
+
+	res := *ast
+	res.Recipe.Metadata = make([]aromalang.Metadata, len(ast.Recipe.Metadata))
+	for i, md := range ast.Recipe.Metadata {
+		if strings.EqualFold(md.Key, "servings") && o.Scale != 1 {
+			if s, ok := ast.Servings(); ok {
+				md.Value = o.number(s * o.Scale)
+			}
+		}
+		res.Recipe.Metadata[i] = md
+	}
+
+	res.Recipe.Steps = make([]aromalang.Step, len(ast.Recipe.Steps))
+	for i, step := range ast.Recipe.Steps {
+		comps := make([]aromalang.Component, len(step.Components))
+		for j, c := range step.Components {
+			if ing, ok := c.(aromalang.Ingredient); ok {
+				c = o.ingredient(ing)
+			}
+			comps[j] = c
+		}
+		step.Components = comps
+		res.Recipe.Steps[i] = step
+	}
+
+	return &res
+}
+
+func (o Options) ingredient(ing aromalang.Ingredient) aromalang.Ingredient {
+	q, err := conversion.ParseQuantity(ing.Quantity, ing.Unit)
+	if err != nil {
+		return ing
+	}
+
+	q = q.Scale(o.Scale).To(o.Units)
+	ing.Quantity = o.number(q.Value)
+	if q.Unit.Dimension != conversion.DimensionNone {
+		ing.Unit = q.Unit.Symbol
+	}
+	return ing
+}
+
+func (o Options) number(f float64) string {
+	return strings.Replace(conversion.FormatFloat(f), ".", o.decimalSeparator(), 1)
+}
+
+func (o Options) decimalSeparator() string {
+	switch o.language() {
+	case "sv", "de", "fr", "es", "it", "nl", "da", "nb", "fi":
+		return ","
+	default:
+		return "."
+	}
+}
+
+func (o Options) language() string {
+	lang, _, _ := strings.Cut(strings.ToLower(o.Locale), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	return lang
+}
+
+var labels = map[string]map[string]string{
+	"en": {
+		"ingredients": "Ingredients",
+		"cookware":    "Cookware",
+		"steps":       "Steps",
+		"servings":    "Servings",
+		"source":      "Source",
+		"step":        "Step",
+		"previous":    "Previous",
+		"next":        "Next",
+		"done":        "Done",
+	},
+	"sv": {
+		"ingredients": "Ingredienser",
+		"cookware":    "Köksredskap",
+		"steps":       "Gör så här",
+		"servings":    "Portioner",
+		"source":      "Källa",
+		"step":        "Steg",
+		"previous":    "Föregående",
+		"next":        "Nästa",
+		"done":        "Klar",
+	},
+}
+
+// label returns the translation of key for the options' locale,
+// falling back to English.
+func (o Options) label(key string) string {
+	if l, ok := labels[o.language()][key]; ok {
+		return l
+	}
+	return labels["en"][key]
+}
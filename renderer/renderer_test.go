@@ -0,0 +1,308 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRegistry(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	for _, name := range Formats() {
+		r, err := New(name, ast)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		buf := bytes.Buffer{}
+		if err := r.RenderTo(&buf, Options{Scale: 2}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if buf.Len() == 0 {
+			t.Errorf("%s: rendered nothing", name)
+		}
+	}
+
+	if _, err := New("nonexistent", ast); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// futureComponent is a component that the renderers don't know about.
+type futureComponent struct {
+	aromalang.Base
+}
+
+func (futureComponent) String() string { return "(future)" }
+
+func TestUnknownComponent(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	step := &ast.Recipe.Steps[0]
+	step.Components = append(step.Components, futureComponent{})
+
+	for _, name := range Formats() {
+		r, err := New(name, ast)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		buf := bytes.Buffer{}
+		if err := r.RenderTo(&buf, Options{}); err != nil {
+			t.Errorf("%s: expected unknown components to be left out, got %v", name, err)
+		}
+		if strings.Contains(buf.String(), "future") {
+			t.Errorf("%s: expected unknown components to be left out, got:\n%s", name, buf.String())
+		}
+	}
+}
+
+func TestOptionsApply(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	scaled := Options{Scale: 0.5, Units: conversion.SystemImperial, Locale: "sv-SE"}.Apply(ast)
+	flour := scaled.Recipe.Steps[0].Ingredients()[1]
+	if flour.Quantity != "2,2" || flour.Unit != "oz" {
+		t.Errorf("expected 2,2 oz flour, got %s", flour)
+	}
+	if orig := ast.Recipe.Steps[0].Ingredients()[1]; orig.Quantity != "125" {
+		t.Errorf("expected original recipe to be unchanged, got %s", orig)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	buf := bytes.Buffer{}
+	if err := (JSON{AST: ast}).RenderTo(&buf, Options{Scale: 2}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	var res jsonRecipe
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(res.Steps) != 6 || res.Ingredients[0].Quantity != 6.0 {
+		t.Errorf("expected 6 steps and 6 eggs, got %+v", res)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := Markdown{AST: ast}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	fmt.Println(string(b))
+
+	md := string(b)
+	for _, expected := range []string{
+		"# pancakes\n",
+		"- 125 g flour\n",
+		"- large non-stick frying pan\n",
+		"1. Crack the **eggs** into a blender",
+		"2. Pour into a bowl and leave to stand for *15 minutes*.\n",
+	} {
+		if !strings.Contains(md, expected) {
+			t.Errorf("expected markdown to contain %q", expected)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := Text{AST: ast, Width: 60}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	fmt.Println(string(b))
+
+	for _, line := range strings.Split(string(b), "\n") {
+		if n := len([]rune(line)); n > 60 && !strings.Contains(line, "https://") {
+			t.Errorf("expected lines to be wrapped at 60 columns, got %d: %q", n, line)
+		}
+	}
+	if strings.Contains(string(b), "\x1b[") {
+		t.Error("expected no escape codes without color")
+	}
+
+	colored, err := Text{AST: ast, Width: 60, Color: true}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.Contains(string(colored), string(textIngredient)+"flour"+textReset+",") {
+		t.Error("expected ingredients to be highlighted")
+	}
+
+	// The registered format doesn't know where its output goes, so
+	// it leaves color to the caller.
+	r, err := New("text", ast)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if r.(Text).Color {
+		t.Error("expected the text format to default to no color")
+	}
+}
+
+func TestPage(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := Page{AST: ast}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	fmt.Println(string(b))
+
+	page := string(b)
+	for _, expected := range []string{
+		"<!DOCTYPE html>",
+		"<title>pancakes</title>",
+		`<a href="https://www.jamieoliver.com/recipes/eggs-recipes/easy-pancakes/">`,
+		`<span class="cook-quantity">125 g</span> <span class="cook-ingredient">flour</span>`,
+		"@media print",
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected page to contain %q", expected)
+		}
+	}
+
+	themed, err := Page{AST: ast, Templates: fstest.MapFS{
+		"theme.html": {Data: []byte(`{{ define "style" }}body { color: teal; }{{ end }}`)},
+	}}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !strings.Contains(string(themed), "body { color: teal; }") || strings.Contains(string(themed), "@media print") {
+		t.Error("expected the theme to replace the default style")
+	}
+}
+
+func TestJSONLD(t *testing.T) {
+	ast, err := aromalang.Parse("bread.aroma", strings.NewReader(`(recipe {
+	"title" "Flatbread"
+	"author" "Jane Doe"
+	"servings" "4"
+	"prep time" "15 minutes"
+	"cook time" "1 hour"
+}
+[(step {} [(instruction "Mix ") (ingredient "flour" {:quantity "300" :unit "g"}) (instruction " in a ") (cookware "bowl")])
+(step {} [(instruction "Bake for ") (timer "" {:magnitude "1" :unit "hour"})])])`))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	buf := bytes.Buffer{}
+	if err := (JSONLD{AST: ast}).RenderTo(&buf, Options{Scale: 2}); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	fmt.Println(buf.String())
+
+	var res schemaRecipe
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	if res.Type != "Recipe" || res.Name != "Flatbread" || res.Author.Name != "Jane Doe" || res.RecipeYield != "8" {
+		t.Errorf("unexpected recipe %+v", res)
+	}
+	if res.PrepTime != "PT15M" || res.CookTime != "PT1H" || res.TotalTime != "PT1H15M" {
+		t.Errorf("unexpected times %s %s %s", res.PrepTime, res.CookTime, res.TotalTime)
+	}
+	if len(res.RecipeIngredient) != 1 || res.RecipeIngredient[0] != "600 g flour" {
+		t.Errorf("unexpected ingredients %v", res.RecipeIngredient)
+	}
+	if len(res.RecipeInstructions) != 2 || res.RecipeInstructions[1].Text != "Bake for 1 hour" {
+		t.Errorf("unexpected instructions %v", res.RecipeInstructions)
+	}
+
+	page, err := Page{AST: ast, JSONLD: true, Microdata: true}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, expected := range []string{
+		`<script type="application/ld+json">{"@context":"https://schema.org"`,
+		`itemtype="https://schema.org/Recipe"`,
+		`itemprop="recipeIngredient"`,
+	} {
+		if !strings.Contains(string(page), expected) {
+			t.Errorf("expected page to contain %q", expected)
+		}
+	}
+}
+
+func TestCookMode(t *testing.T) {
+	ast, err := aromalang.Parse("pancakes.aroma", strings.NewReader(sample))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	b, err := CookMode{AST: ast}.Render()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	page := string(b)
+	for _, expected := range []string{
+		`<div class="cook-step" id="step-0" data-ingredients="0 1 2 3">`,
+		`<div class="cook-step" id="step-1" data-ingredients="" hidden>`,
+		`data-seconds="900"`,
+		`<input type="checkbox"> <span class="cook-quantity">125 g</span> flour`,
+		`navigator.wakeLock.request("screen")`,
+	} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("expected cook mode to contain %q", expected)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package renderer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Text renders a recipe as plain text for reading in a terminal, with
+// an ingredient summary at the top followed by numbered steps wrapped
+// to Width columns.
+type Text struct {
+	AST *aromalang.AST
+	// Width is the column lines are wrapped at. Lines aren't wrapped
+	// if it's zero or negative.
+	Width int
+	// Color highlights ingredients, cookware and timers with ANSI
+	// escape codes. It is off by default, since it only makes sense
+	// when writing to a terminal, see [IsTerminal].
+	Color bool
+}
+
+// DefaultWidth is the column that the "text" format wraps lines at.
+const DefaultWidth = 80
+
+func init() {
+	Register(Format{
+		Name:        "text",
+		ContentType: "text/plain; charset=utf-8",
+		Extension:   ".txt",
+		New: func(ast *aromalang.AST) Renderer {
+			return Text{AST: ast, Width: DefaultWidth}
+		},
+	})
+}
+
+// IsTerminal returns true if f is a terminal, which is when it makes
+// sense to use ANSI escape codes.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+type textStyle string
+
+const (
+	textPlain      textStyle = ""
+	textBold       textStyle = "\x1b[1m"
+	textDim        textStyle = "\x1b[2m"
+	textIngredient textStyle = "\x1b[1;33m"
+	textCookware   textStyle = "\x1b[36m"
+	textTimer      textStyle = "\x1b[35m"
+	textReset                = "\x1b[0m"
+)
+
+// textSpan is a piece of text in a single style.
+type textSpan struct {
+	text  string
+	style textStyle
+}
+
+func (t Text) Render() ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := t.RenderTo(&buf, Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (t Text) RenderTo(w io.Writer, opts Options) error {
+	if t.AST == nil {
+		return fmt.Errorf("no recipe provided")
+	}
+	ast := opts.Apply(t.AST)
+
+	steps, err := renderSteps(ast.Recipe.Steps, textRenderComponent)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	t.writeLines(bw, "", "", textSpan{text: ast.Title(), style: textBold})
+	for _, md := range displayMetadata(ast) {
+		t.writeLines(bw, "", "  ", textSpan{text: md.Key + ": ", style: textDim}, textSpan{text: md.Value})
+	}
+
+	if list := opts.ingredientList(t.AST); len(list) != 0 {
+		bw.WriteByte('\n')
+		t.writeLines(bw, "", "", textSpan{text: opts.label("ingredients"), style: textBold})
+
+		width := 0
+		for _, item := range list {
+			if n := utf8.RuneCountInString(item.Quantity); n > width {
+				width = n
+			}
+		}
+		for _, item := range list {
+			pad := strings.Repeat(" ", width-utf8.RuneCountInString(item.Quantity))
+			indent := "  " + strings.Repeat(" ", width+2)
+			t.writeLines(bw, "  "+item.Quantity+pad+"  ", indent,
+				textSpan{text: item.Name, style: textIngredient},
+			)
+		}
+	}
+
+	if list := cookwareList(ast); len(list) != 0 {
+		bw.WriteByte('\n')
+		t.writeLines(bw, "", "", textSpan{text: opts.label("cookware"), style: textBold})
+		for _, name := range list {
+			t.writeLines(bw, "  ", "    ", textSpan{text: name, style: textCookware})
+		}
+	}
+
+	n := 0
+	for _, spans := range steps {
+		var text, comments []textSpan
+		for _, s := range spans {
+			if s.style == textDim {
+				comments = append(comments, s)
+			} else {
+				text = append(text, s)
+			}
+		}
+		if textEmpty(text) && len(comments) == 0 {
+			continue
+		}
+
+		n++
+		prefix := fmt.Sprintf("%d. ", n)
+		indent := strings.Repeat(" ", len(prefix))
+		bw.WriteByte('\n')
+		if textEmpty(text) {
+			text = nil
+		}
+		t.writeLines(bw, prefix, indent, text...)
+		for _, c := range comments {
+			t.writeLines(bw, indent, indent+"   ", textSpan{text: "-- " + c.text, style: textDim})
+		}
+	}
+
+	return bw.Flush()
+}
+
+func textRenderComponent(component aromalang.Component) (textSpan, bool, error) {
+	switch c := component.(type) {
+	case aromalang.Instruction:
+		return textSpan{text: c.Instruction}, false, nil
+	case aromalang.Ingredient:
+		return textSpan{text: c.Name, style: textIngredient}, false, nil
+	case aromalang.Cookware:
+		return textSpan{text: c.Name, style: textCookware}, false, nil
+	case aromalang.Timer:
+		return textSpan{text: strings.TrimSpace(c.Magnitude + " " + c.Unit), style: textTimer}, false, nil
+	case aromalang.Comment:
+		return textSpan{text: strings.TrimSpace(c.Comment), style: textDim}, false, nil
+	case aromalang.Metadata:
+		return textSpan{}, true, nil
+	default:
+		// Leave out components that this version of the renderer
+		// doesn't know about, rather than failing the whole recipe.
+		return textSpan{}, true, nil
+	}
+}
+
+func textEmpty(spans []textSpan) bool {
+	for _, s := range spans {
+		if strings.TrimSpace(s.text) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// writeLines writes the spans as a paragraph wrapped at t.Width. The
+// first line starts with prefix and the following lines with indent.
+// Wrapping only counts visible characters, so that escape codes don't
+// make lines shorter than they should be.
+func (t Text) writeLines(w *bufio.Writer, prefix, indent string, spans ...textSpan) {
+	words := textWords(spans)
+
+	w.WriteString(prefix)
+	col := utf8.RuneCountInString(prefix)
+	lineStart := true
+	for _, word := range words {
+		n := 0
+		for _, s := range word {
+			n += utf8.RuneCountInString(s.text)
+		}
+
+		if !lineStart {
+			if t.Width > 0 && col+1+n > t.Width {
+				w.WriteByte('\n')
+				w.WriteString(indent)
+				col = utf8.RuneCountInString(indent)
+			} else {
+				w.WriteByte(' ')
+				col++
+			}
+		}
+
+		for _, s := range word {
+			t.writeSpan(w, s)
+		}
+		col += n
+		lineStart = false
+	}
+	w.WriteByte('\n')
+}
+
+func (t Text) writeSpan(w *bufio.Writer, s textSpan) {
+	if !t.Color || s.style == textPlain {
+		w.WriteString(s.text)
+		return
+	}
+	w.WriteString(string(s.style))
+	w.WriteString(s.text)
+	w.WriteString(textReset)
+}
+
+// textWords splits spans into words at whitespace. A word may consist
+// of several spans, like an ingredient followed by a comma.
+func textWords(spans []textSpan) [][]textSpan {
+	var (
+		words [][]textSpan
+		word  []textSpan
+	)
+
+	for _, s := range spans {
+		start := 0
+		inWord := false
+		for i, r := range s.text {
+			switch {
+			case unicode.IsSpace(r) && inWord:
+				word = append(word, textSpan{text: s.text[start:i], style: s.style})
+				inWord = false
+				fallthrough
+			case unicode.IsSpace(r):
+				if len(word) != 0 {
+					words = append(words, word)
+					word = nil
+				}
+			case !inWord:
+				start = i
+				inWord = true
+			}
+		}
+		if inWord {
+			word = append(word, textSpan{text: s.text[start:], style: s.style})
+		}
+	}
+	if len(word) != 0 {
+		words = append(words, word)
+	}
+	return words
+}
@@ -0,0 +1,95 @@
+package renderer
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/ingredients"
+	"strings"
+)
+
+// renderSteps renders every component of every step using render,
+// which is the traversal all renderers share. Components for which
+// render returns skip are left out.
+func renderSteps[T any](steps []aromalang.Step, render func(aromalang.Component) (r T, skip bool, err error)) ([][]T, error) {
+	res := make([][]T, 0, len(steps))
+	for _, step := range steps {
+		comps := []T{}
+		for _, c := range step.Components {
+			r, skip, err := render(c)
+			if err != nil {
+				return nil, err
+			}
+			if skip {
+				continue
+			}
+			comps = append(comps, r)
+		}
+		res = append(res, comps)
+	}
+	return res, nil
+}
+
+type listItem struct {
+	Name     string
+	Quantity string
+}
+
+// ingredientList combines the ingredients of the recipe, after
+// applying the scale and units of the options, into a list with
+// localized quantities.
+func (o Options) ingredientList(ast *aromalang.AST) []listItem {
+	l := &ingredients.List{}
+	l.AddRecipe(Options{Scale: o.Scale, Units: o.Units}.Apply(ast), 1)
+
+	items := make([]listItem, 0, l.Len())
+	for _, item := range l.Items() {
+		items = append(items, listItem{
+			Name:     item.Name,
+			Quantity: o.Quantity(item),
+		})
+	}
+	return items
+}
+
+// Quantity returns the amounts of an item of an ingredient list with
+// the numbers formatted for the options' locale. The list should be
+// built from a recipe that isn't localized, since localized quantities
+// can't be added up.
+func (o Options) Quantity(item ingredients.Item) string {
+	parts := make([]string, 0, len(item.Amounts)+len(item.Notes))
+	for _, a := range item.Amounts {
+		parts = append(parts, strings.TrimSpace(o.number(a.Value)+" "+a.Unit.Symbol))
+	}
+	parts = append(parts, item.Notes...)
+	return strings.Join(parts, " + ")
+}
+
+// cookwareList returns the names of all cookware in the recipe,
+// without duplicates.
+func cookwareList(ast *aromalang.AST) []string {
+	seen := map[string]struct{}{}
+	list := []string{}
+	for _, step := range ast.Recipe.Steps {
+		for _, c := range step.Cookware() {
+			k := strings.ToLower(strings.TrimSpace(c.Name))
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			list = append(list, strings.TrimSpace(c.Name))
+		}
+	}
+	return list
+}
+
+// displayMetadata returns the recipe's metadata that isn't shown
+// elsewhere when rendering a full recipe.
+func displayMetadata(ast *aromalang.AST) []aromalang.Metadata {
+	list := []aromalang.Metadata{}
+	for _, md := range ast.Recipe.Metadata {
+		if strings.EqualFold(md.Key, "title") {
+			continue
+		}
+		list = append(list, md)
+	}
+	return list
+}
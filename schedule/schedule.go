@@ -0,0 +1,167 @@
+// Package schedule plans when to start each step of one or more
+// recipes so that they're all done at the same time.
+package schedule
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultStepTime is the time assumed for steps without timers.
+const DefaultStepTime = 5 * time.Minute
+
+// StepTimeKeys are the step metadata keys that override the duration
+// of a step.
+var StepTimeKeys = []string{"time", "duration"}
+
+// Scheduler builds timelines.
+type Scheduler struct {
+	// StepTime is the time it takes to do a step that doesn't have
+	// any timers or a duration in its metadata.
+	StepTime time.Duration
+}
+
+// Timeline is a list of steps to perform, sorted by the time they
+// should be started.
+type Timeline struct {
+	Serve time.Time
+	Steps []Step
+	// Errors are problems with durations that were ignored while
+	// building the timeline, such as timers with unknown units.
+	Errors []error
+}
+
+// Step is a step of a recipe placed in time.
+type Step struct {
+	Recipe *aromalang.AST
+	// Index is the position of the step within the recipe, starting
+	// from 0.
+	Index    int
+	Step     aromalang.Step
+	Start    time.Time
+	End      time.Time
+	Duration conversion.TimeDiff
+	Timers   []Timer
+}
+
+// Timer is a timer within a step. Timers within a step are assumed to
+// run one after the other.
+type Timer struct {
+	Timer    aromalang.Timer
+	Start    time.Time
+	End      time.Time
+	Duration conversion.TimeDiff
+}
+
+// Backwards schedules the recipes to be served at serve using
+// DefaultStepTime for steps without timers.
+func Backwards(serve time.Time, recipes ...*aromalang.AST) Timeline {
+	return Scheduler{StepTime: DefaultStepTime}.Backwards(serve, recipes...)
+}
+
+// Backwards works backwards from serve to find when each step of the
+// recipes has to be started. Every recipe is finished at serve, and
+// the steps of a recipe are done one after the other.
+func (s Scheduler) Backwards(serve time.Time, recipes ...*aromalang.AST) Timeline {
+	tl := Timeline{Serve: serve}
+
+	for _, r := range recipes {
+		end := serve
+		steps := r.Recipe.Steps
+		scheduled := make([]Step, 0, len(steps))
+
+		for i := len(steps) - 1; i >= 0; i-- {
+			step := s.step(&tl, r, i)
+			step.End = end
+			step.Start = step.Duration.Negate().AddTime(end)
+			step.placeTimers()
+			end = step.Start
+
+			scheduled = append(scheduled, step)
+		}
+
+		for i := len(scheduled) - 1; i >= 0; i-- {
+			tl.Steps = append(tl.Steps, scheduled[i])
+		}
+	}
+
+	sort.SliceStable(tl.Steps, func(i, j int) bool {
+		return tl.Steps[i].Start.Before(tl.Steps[j].Start)
+	})
+	return tl
+}
+
+func (s Scheduler) step(tl *Timeline, recipe *aromalang.AST, index int) Step {
+	step := Step{
+		Recipe: recipe,
+		Index:  index,
+		Step:   recipe.Recipe.Steps[index],
+	}
+
+	for _, t := range step.Step.Timers() {
+		d, err := t.Duration()
+		if err != nil {
+			tl.Errors = append(tl.Errors, aromalang.NewErrorf(t.Position(), "%v", err))
+			continue
+		}
+		step.Timers = append(step.Timers, Timer{Timer: t, Duration: d})
+		step.Duration = step.Duration.Add(d)
+	}
+
+	for _, md := range step.Step.Metadata() {
+		if !contains(StepTimeKeys, strings.ToLower(md.Key)) {
+			continue
+		}
+		d, err := conversion.ParseDurationText(md.Value)
+		if err != nil {
+			tl.Errors = append(tl.Errors, aromalang.NewErrorf(md.Position(), "%v", err))
+			continue
+		}
+		step.Duration = d
+	}
+
+	if step.Duration.IsZero() {
+		step.Duration = conversion.TimeDiff{Duration: s.StepTime}
+	}
+	return step
+}
+
+func (s *Step) placeTimers() {
+	start := s.Start
+	for i := range s.Timers {
+		s.Timers[i].Start = start
+		s.Timers[i].End = s.Timers[i].Duration.AddTime(start)
+		start = s.Timers[i].End
+	}
+}
+
+// Start returns the time the first step has to be started.
+func (t Timeline) Start() time.Time {
+	if len(t.Steps) == 0 {
+		return t.Serve
+	}
+	return t.Steps[0].Start
+}
+
+func (t Timeline) String() string {
+	b := strings.Builder{}
+	for _, s := range t.Steps {
+		b.WriteString(fmt.Sprintf("%s  %s, step %d: %s\n",
+			s.Start.Format("Mon 15:04"), s.Recipe.Title(), s.Index+1, s.Step.Text()))
+	}
+	b.WriteString(fmt.Sprintf("%s  serve\n", t.Serve.Format("Mon 15:04")))
+	return b.String()
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/internal/load"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBackwards(t *testing.T) {
+	bread, err := load.File(os.DirFS("testdata"), "bread.cook")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	serve := time.Date(2022, time.October, 23, 20, 0, 0, 0, time.UTC)
+	tl := Backwards(serve, bread)
+	fmt.Print(tl)
+
+	if len(tl.Errors) != 0 {
+		t.Error(tl.Errors)
+	}
+
+	expected := time.Date(2022, time.October, 21, 16, 0, 0, 0, time.UTC)
+	if !tl.Start().Equal(expected) {
+		t.Errorf("expected to start at %s, got %s", expected, tl.Start())
+	}
+
+	bake := tl.Steps[2]
+	if len(bake.Timers) != 2 || !bake.Timers[1].Start.Equal(serve.Add(-20*time.Minute)) {
+		t.Errorf("expected the bread to cool for the last 20 minutes, got %+v", bake.Timers)
+	}
+}
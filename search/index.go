@@ -0,0 +1,256 @@
+// Package search is a full text index of recipes, with ranked results,
+// prefix matching and filters on ingredients, cookware, tags and
+// metadata. Ingredient names are indexed by their canonical names, so
+// that a search for "egg" finds recipes calling for "Eggs".
+package search
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/load"
+	"github.com/dememorized/cook/library"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Weights of a word depending on where in the recipe it is found.
+const (
+	weightTitle      = 4
+	weightTag        = 3
+	weightIngredient = 3
+	weightCookware   = 2
+	weightMetadata   = 1.5
+	weightText       = 1
+)
+
+// Index is an inverted index of recipes by the words in their titles,
+// metadata, ingredients, cookware and instructions. The zero value is
+// not usable, create indexes with [New] or [Read]. An Index is not safe
+// for concurrent use.
+type Index struct {
+	// Names are the synonyms used for ingredient and cookware names.
+	// Recipes are indexed with the synonyms at the time they are
+	// added, so it should be the same for every recipe.
+	Names *canonical.Dictionary
+
+	docs     map[string]*document
+	postings map[string]map[string]float64
+	// terms are the keys of postings sorted, for prefix searches. It
+	// is nil when it has to be rebuilt.
+	terms []string
+}
+
+// document is what the index knows about a recipe.
+type document struct {
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	// Tags, Ingredients and Cookware are canonical keys.
+	Tags        []string          `json:"tags,omitempty"`
+	Ingredients []string          `json:"ingredients,omitempty"`
+	Cookware    []string          `json:"cookware,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// Terms are the weighted number of occurrences of each word.
+	Terms  map[string]float64 `json:"terms"`
+	Length float64            `json:"length"`
+	// Size and ModTime are those of the file the recipe was read from
+	// by [Index.Update], for telling whether it has changed since.
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// New returns an empty index using the synonyms in names, which may be
+// nil.
+func New(names *canonical.Dictionary) *Index {
+	return &Index{
+		Names:    names,
+		docs:     map[string]*document{},
+		postings: map[string]map[string]float64{},
+	}
+}
+
+// FromLibrary returns an index of every recipe in lib.
+func FromLibrary(lib *library.Library, names *canonical.Dictionary) *Index {
+	idx := New(names)
+	for _, r := range lib.Recipes {
+		idx.Add(r.Path, r.AST)
+	}
+	return idx
+}
+
+// Len returns the number of recipes in the index.
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Paths returns the paths of the recipes in the index, sorted.
+func (idx *Index) Paths() []string {
+	paths := make([]string, 0, len(idx.docs))
+	for p := range idx.docs {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Add indexes the recipe at path, replacing what was indexed for it
+// before.
+func (idx *Index) Add(path string, ast *aromalang.AST) {
+	idx.Remove(path)
+
+	doc := &document{
+		Path:     path,
+		Title:    ast.Title(),
+		Metadata: map[string]string{},
+		Terms:    map[string]float64{},
+	}
+	add := func(text string, weight float64) {
+		for _, w := range words(text) {
+			doc.Terms[w] += weight
+			doc.Length += weight
+		}
+	}
+
+	add(doc.Title, weightTitle)
+	for _, md := range ast.Recipe.Metadata {
+		key := strings.ToLower(strings.TrimSpace(md.Key))
+		value := strings.TrimSpace(md.Value)
+		switch key {
+		case "title":
+			continue
+		case "tags":
+			for _, t := range strings.Split(value, ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					doc.Tags = append(doc.Tags, strings.ToLower(t))
+					add(t, weightTag)
+				}
+			}
+		default:
+			add(value, weightMetadata)
+		}
+		doc.Metadata[key] = value
+	}
+
+	for _, step := range ast.Recipe.Steps {
+		for _, c := range step.Components {
+			switch c := c.(type) {
+			case aromalang.Instruction:
+				add(c.Instruction, weightText)
+			case aromalang.Ingredient:
+				k := idx.Names.Key(c.Name)
+				doc.Ingredients = appendUnique(doc.Ingredients, k)
+				add(c.Name, weightIngredient)
+				if k != canonical.Key(c.Name) {
+					add(k, weightIngredient)
+				}
+			case aromalang.Cookware:
+				k := idx.Names.Key(c.Name)
+				doc.Cookware = appendUnique(doc.Cookware, k)
+				add(c.Name, weightCookware)
+				if k != canonical.Key(c.Name) {
+					add(k, weightCookware)
+				}
+			}
+		}
+	}
+
+	idx.insert(doc)
+}
+
+// Remove removes the recipe at path from the index.
+func (idx *Index) Remove(path string) {
+	doc, ok := idx.docs[path]
+	if !ok {
+		return
+	}
+	for term := range doc.Terms {
+		delete(idx.postings[term], path)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+			idx.terms = nil
+		}
+	}
+	delete(idx.docs, path)
+}
+
+// Update re-indexes the recipe called name in fsys after it has
+// changed, or removes it if it no longer exists. If the recipe can't
+// be parsed it is removed and the error is returned.
+func (idx *Index) Update(fsys fs.FS, name string) error {
+	// The file is looked at before it is read, so that a change while
+	// it is being read is seen by the next call to Changed.
+	info, statErr := fs.Stat(fsys, name)
+	ast, err := load.File(fsys, name)
+	if err != nil {
+		idx.Remove(name)
+		if statErr != nil {
+			return nil
+		}
+		return err
+	}
+	idx.Add(name, ast)
+	if statErr == nil {
+		doc := idx.docs[name]
+		doc.Size, doc.ModTime = info.Size(), info.ModTime()
+	}
+	return nil
+}
+
+// Changed returns true if the recipe called name has to be indexed
+// again, because it isn't in the index or info has another size or
+// modification time than the file it was read from by [Index.Update].
+func (idx *Index) Changed(name string, info fs.FileInfo) bool {
+	doc, ok := idx.docs[name]
+	return !ok || doc.Size != info.Size() || !doc.ModTime.Equal(info.ModTime())
+}
+
+func (idx *Index) insert(doc *document) {
+	idx.docs[doc.Path] = doc
+	for term, n := range doc.Terms {
+		p, ok := idx.postings[term]
+		if !ok {
+			p = map[string]float64{}
+			idx.postings[term] = p
+			idx.terms = nil
+		}
+		p[doc.Path] = n
+	}
+}
+
+// sortedTerms returns every term in the index, sorted.
+func (idx *Index) sortedTerms() []string {
+	if idx.terms == nil {
+		idx.terms = make([]string, 0, len(idx.postings))
+		for term := range idx.postings {
+			idx.terms = append(idx.terms, term)
+		}
+		sort.Strings(idx.terms)
+	}
+	return idx.terms
+}
+
+// words splits text into lower case words with the plural forms made
+// singular.
+func words(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, f := range fields {
+		fields[i] = canonical.Singular(f)
+	}
+	return fields
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
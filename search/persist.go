@@ -0,0 +1,57 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"io"
+	"sort"
+)
+
+// formatVersion is increased when the way recipes are indexed changes,
+// so that indexes written by older versions are rebuilt.
+const formatVersion = 1
+
+type persisted struct {
+	Version   int         `json:"version"`
+	Documents []*document `json:"documents"`
+}
+
+// WriteTo writes the index as JSON, which can be read back with [Read].
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	p := persisted{Version: formatVersion, Documents: make([]*document, 0, len(idx.docs))}
+	for _, doc := range idx.docs {
+		p.Documents = append(p.Documents, doc)
+	}
+	sort.Slice(p.Documents, func(i, j int) bool {
+		return p.Documents[i].Path < p.Documents[j].Path
+	})
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Read reads an index written by [Index.WriteTo]. The synonyms in
+// names should be the same as when the index was written.
+func Read(r io.Reader, names *canonical.Dictionary) (*Index, error) {
+	var p persisted
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("invalid search index: %w", err)
+	}
+	if p.Version != formatVersion {
+		return nil, fmt.Errorf("search index has version %d, expected %d", p.Version, formatVersion)
+	}
+
+	idx := New(names)
+	for _, doc := range p.Documents {
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		idx.insert(doc)
+	}
+	return idx, nil
+}
@@ -0,0 +1,272 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Result is a recipe matching a search.
+type Result struct {
+	Path  string
+	Title string
+	// Score is higher for better matches. It is zero for searches
+	// with only filters.
+	Score float64
+}
+
+// filter is a field:value term of a query.
+type filter struct {
+	field  string
+	value  string
+	negate bool
+}
+
+// query is a parsed search.
+type query struct {
+	terms    []string
+	prefixes []string
+	excluded []string
+	filters  []filter
+}
+
+// Search returns the recipes matching every word and filter of q, best
+// matches first. Words match the words of a recipe's title, tags,
+// metadata, ingredients, cookware and instructions, and match every
+// word they are the beginning of if they end with *. Filters are
+// written as field:value:
+//
+//	ingredient:butter  uses an ingredient called butter, or a more
+//	                   specific kind of butter such as "salted butter"
+//	cookware:wok       uses the cookware
+//	tag:dessert        is tagged with dessert
+//	course:dinner      has metadata course containing dinner
+//
+// Values with spaces are written within double quotes, as in
+// ingredient:"olive oil". A minus sign before a word or a filter
+// excludes the recipes that match it.
+func (idx *Index) Search(q string) ([]Result, error) {
+	parsed, err := parseQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*document
+	for _, doc := range idx.docs {
+		if parsed.match(idx, doc) {
+			candidates = append(candidates, doc)
+		}
+	}
+
+	avg := 0.0
+	for _, doc := range idx.docs {
+		avg += doc.Length
+	}
+	if len(idx.docs) != 0 {
+		avg /= float64(len(idx.docs))
+	}
+
+	results := make([]Result, 0, len(candidates))
+	for _, doc := range candidates {
+		r := Result{Path: doc.Path, Title: doc.Title}
+		for _, t := range parsed.terms {
+			r.Score += idx.score(t, doc, avg)
+		}
+		for _, p := range parsed.prefixes {
+			best := 0.0
+			for _, t := range idx.expand(p) {
+				best = math.Max(best, idx.score(t, doc, avg))
+			}
+			r.Score += best
+		}
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		ti, tj := strings.ToLower(results[i].Title), strings.ToLower(results[j].Title)
+		if ti != tj {
+			return ti < tj
+		}
+		return results[i].Path < results[j].Path
+	})
+	return results, nil
+}
+
+// score ranks how well term matches doc using BM25.
+func (idx *Index) score(term string, doc *document, avgLength float64) float64 {
+	const k1, b = 1.2, 0.75
+
+	tf := doc.Terms[term]
+	if tf == 0 {
+		return 0
+	}
+	n, df := float64(len(idx.docs)), float64(len(idx.postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	norm := 1 - b + b*doc.Length/math.Max(avgLength, 1)
+	return idf * tf * (k1 + 1) / (tf + k1*norm)
+}
+
+// expand returns the terms in the index starting with prefix.
+func (idx *Index) expand(prefix string) []string {
+	terms := idx.sortedTerms()
+	i := sort.SearchStrings(terms, prefix)
+	var res []string
+	for ; i < len(terms) && strings.HasPrefix(terms[i], prefix); i++ {
+		res = append(res, terms[i])
+	}
+	return res
+}
+
+func (q query) match(idx *Index, doc *document) bool {
+	for _, t := range q.terms {
+		if doc.Terms[t] == 0 {
+			return false
+		}
+	}
+	for _, p := range q.prefixes {
+		found := false
+		for _, t := range idx.expand(p) {
+			if doc.Terms[t] != 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, t := range q.excluded {
+		if doc.Terms[t] != 0 {
+			return false
+		}
+	}
+	for _, f := range q.filters {
+		if f.match(idx, doc) == f.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filter) match(idx *Index, doc *document) bool {
+	switch f.field {
+	case "ingredient":
+		return containsName(doc.Ingredients, idx.Names.Key(f.value))
+	case "cookware":
+		return containsName(doc.Cookware, idx.Names.Key(f.value))
+	case "tag":
+		for _, t := range doc.Tags {
+			if t == strings.ToLower(f.value) {
+				return true
+			}
+		}
+		return false
+	default:
+		v, ok := doc.Metadata[f.field]
+		return ok && strings.Contains(strings.ToLower(v), strings.ToLower(f.value))
+	}
+}
+
+// containsName returns true if any of the canonical names in keys is
+// key or a more specific variety of it.
+func containsName(keys []string, key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, k := range keys {
+		if strings.Contains(" "+k+" ", " "+key+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQuery splits a search into words and filters.
+func parseQuery(s string) (query, error) {
+	var q query
+
+	fields, err := splitQuery(s)
+	if err != nil {
+		return q, err
+	}
+	for _, field := range fields {
+		negate := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		if name, value, ok := strings.Cut(field, ":"); ok && name != "" {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				return q, fmt.Errorf("missing value for filter '%s'", name)
+			}
+			name = strings.ToLower(name)
+			if name == "tags" || name == "ingredients" {
+				name = strings.TrimSuffix(name, "s")
+			}
+			q.filters = append(q.filters, filter{field: name, value: value, negate: negate})
+			continue
+		}
+
+		ws := words(field)
+		switch {
+		case len(ws) == 0:
+			continue
+		case negate:
+			q.excluded = append(q.excluded, ws...)
+		case strings.HasSuffix(field, "*"):
+			// Only the last word of something like "pan-fr*" is a
+			// prefix, and it isn't made singular.
+			q.terms = append(q.terms, ws[:len(ws)-1]...)
+			q.prefixes = append(q.prefixes, strings.ToLower(lastWord(field)))
+		default:
+			q.terms = append(q.terms, ws...)
+		}
+	}
+	return q, nil
+}
+
+// lastWord returns the last run of letters and digits in s.
+func lastWord(s string) string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// splitQuery splits s at whitespace outside double quotes, removing
+// the quotes.
+func splitQuery(s string) ([]string, error) {
+	var fields []string
+	b := strings.Builder{}
+	quoted, started := false, false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case unicode.IsSpace(r) && !quoted:
+			if started {
+				fields = append(fields, b.String())
+				b.Reset()
+				started = false
+			}
+		default:
+			b.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in search")
+	}
+	if started {
+		fields = append(fields, b.String())
+	}
+	return fields, nil
+}
@@ -0,0 +1,146 @@
+package search
+
+import (
+	"bytes"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/library"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var recipes = fstest.MapFS{
+	"cake.cook": {Data: []byte(`>> title: Butter cake
+>> tags: dessert, baking
+
+Beat the @butter{200%g} with the @sugar{200%g} and @eggs{4}. Bake in a #cake tin{}.
+`)},
+	"brownies.cook": {Data: []byte(`>> title: Brownies
+>> tags: dessert
+
+Melt the @salted butter{100%g} with the @chocolate{200%g}, fold in the @walnuts{50%g}.
+`)},
+	"omelette.cook": {Data: []byte(`>> title: Omelette
+>> course: breakfast
+
+Whisk the @egg{2} and fry in @butter{} in a #frying pan{}. Serve with @courgettes{1}.
+`)},
+}
+
+func newTestIndex(t *testing.T) *Index {
+	lib, err := library.Load(recipes, 1)
+	if err != nil || len(lib.Errors) != 0 {
+		t.Error(err, lib.Errors)
+		t.FailNow()
+	}
+	names := canonical.NewDictionary()
+	names.Add("zucchini", "courgette")
+	return FromLibrary(lib, names)
+}
+
+func search(t *testing.T, idx *Index, q string) string {
+	results, err := idx.Search(q)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	list := make([]string, 0, len(results))
+	for _, r := range results {
+		list = append(list, r.Path)
+	}
+	return strings.Join(list, " ")
+}
+
+func TestSearch(t *testing.T) {
+	idx := newTestIndex(t)
+
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{"butter", "cake.cook brownies.cook omelette.cook"},
+		{"EGGS", "omelette.cook cake.cook"},
+		{"choc*", "brownies.cook"},
+		{"bake*", "cake.cook"},
+		{"butter -chocolate", "cake.cook omelette.cook"},
+		{"ingredient:butter -ingredient:walnuts tag:dessert", "cake.cook"},
+		{"ingredient:butter tag:dessert", "brownies.cook cake.cook"},
+		{"ingredient:walnut", "brownies.cook"},
+		{"ingredient:zucchini", "omelette.cook"},
+		{"zucchini", "omelette.cook"},
+		{`cookware:"frying pan"`, "omelette.cook"},
+		{"course:break", "omelette.cook"},
+		{"tag:baking butter", "cake.cook"},
+		{"pizza", ""},
+	} {
+		if res := search(t, idx, tc.query); res != tc.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tc.query, tc.expected, res)
+		}
+	}
+
+	if _, err := idx.Search(`ingredient:"olive oil`); err == nil {
+		t.Errorf("expected error for unterminated quote")
+	}
+}
+
+func TestUpdateAndPersist(t *testing.T) {
+	idx := newTestIndex(t)
+	fsys := fstest.MapFS{}
+	for name, f := range recipes {
+		fsys[name] = f
+	}
+
+	modTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fsys["pancakes.cook"] = &fstest.MapFile{Data: []byte("Whisk @eggs{2} with @butter{}.\n"), ModTime: modTime}
+	if err := idx.Update(fsys, "pancakes.cook"); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if idx.Len() != 4 || !strings.Contains(search(t, idx, "pancake*"), "pancakes.cook") {
+		t.Errorf("expected the new recipe to be indexed")
+	}
+
+	delete(fsys, "brownies.cook")
+	if err := idx.Update(fsys, "brownies.cook"); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if res := search(t, idx, "chocolate"); res != "" {
+		t.Errorf("expected removed recipe to not be found, got %s", res)
+	}
+
+	buf := bytes.Buffer{}
+	if _, err := idx.WriteTo(&buf); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	read, err := Read(&buf, idx.Names)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	for _, q := range []string{"butter", "ingredient:zucchini", "ome*", "course:breakfast"} {
+		if search(t, read, q) != search(t, idx, q) {
+			t.Errorf("%s: expected the same results after reading the index, got '%s'", q, search(t, read, q))
+		}
+	}
+
+	info, err := fs.Stat(fsys, "pancakes.cook")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if read.Changed("pancakes.cook", info) {
+		t.Errorf("expected the recipe to be unchanged after reading the index")
+	}
+	// A recipe that is older than the index may still be new to it.
+	fsys["pancakes.cook"].ModTime = modTime.Add(-time.Hour)
+	if info, _ := fs.Stat(fsys, "pancakes.cook"); !read.Changed("pancakes.cook", info) {
+		t.Errorf("expected a recipe with another modification time to have changed")
+	}
+	if info, _ := fs.Stat(fsys, "omelette.cook"); !read.Changed("omelette.cook", info) {
+		t.Errorf("expected a recipe that wasn't indexed from a file to have changed")
+	}
+}
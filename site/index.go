@@ -0,0 +1,420 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/dememorized/cook/ingredients"
+	"github.com/dememorized/cook/renderer"
+	"html/template"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var labels = map[string]map[string]string{
+	"en": {
+		"recipes":     "Recipes",
+		"ingredients": "Ingredients",
+		"steps":       "Steps",
+		"tags":        "Tags",
+		"categories":  "Categories",
+		"search":      "Search",
+		"scale":       "Scale",
+		"units":       "Units",
+		"any":         "As written",
+		"metric":      "Metric",
+		"imperial":    "Imperial",
+	},
+	"sv": {
+		"recipes":     "Recept",
+		"ingredients": "Ingredienser",
+		"steps":       "Gör så här",
+		"tags":        "Taggar",
+		"categories":  "Kategorier",
+		"search":      "Sök",
+		"scale":       "Skala",
+		"units":       "Enheter",
+		"any":         "Som skrivet",
+		"metric":      "Metriska",
+		"imperial":    "Imperiska",
+	},
+}
+
+type pageData struct {
+	Site    siteData
+	Root    string
+	Title   string
+	Head    template.HTML
+	Recipe  *recipeData
+	Recipes []link
+	Groups  []groupData
+	// Query is the search query on the search page.
+	Query string
+	// Options are the scale and unit system of a served recipe page.
+	Options *optionsData
+}
+
+type siteData struct {
+	Title         string
+	Lang          string
+	Labels        map[string]string
+	HasTags       bool
+	HasCategories bool
+	// Dynamic is true when the pages are served by [Site.Handler],
+	// which adds search, options for recipes and live reloading.
+	Dynamic bool
+	// Version is the fingerprint of the sources the pages were built
+	// from.
+	Version string
+}
+
+type optionsData struct {
+	Scale string
+	Units string
+	// Systems are the unit systems to choose from.
+	Systems []string
+}
+
+type recipeData struct {
+	Title       string
+	Metadata    []metadataData
+	Tags        []link
+	Ingredients []ingredientData
+	Body        template.HTML
+}
+
+type metadataData struct {
+	Key   string
+	Value string
+	Link  string
+}
+
+type ingredientData struct {
+	Name     string
+	Quantity string
+}
+
+type link struct {
+	Title string
+	Name  string
+	Link  string
+}
+
+type groupData struct {
+	Name    string
+	Slug    string
+	Link    string
+	Recipes []link
+}
+
+// group is a set of recipes sharing a tag, category or ingredient.
+type group struct {
+	name    string
+	slug    string
+	recipes []*source
+}
+
+type index struct {
+	site *Site
+	// write stores a page of the site, defaults to writing it to the
+	// site's output directory.
+	write       func(name string, b []byte) error
+	data        siteData
+	recipes     []*source
+	tags        map[string]*group
+	categories  map[string]*group
+	ingredients map[string]*group
+}
+
+func newIndex(s *Site, recipes []*source) *index {
+	idx := &index{
+		site:        s,
+		write:       s.write,
+		recipes:     append([]*source{}, recipes...),
+		tags:        map[string]*group{},
+		categories:  map[string]*group{},
+		ingredients: map[string]*group{},
+	}
+
+	sort.SliceStable(idx.recipes, func(i, j int) bool {
+		return strings.ToLower(idx.recipes[i].ast.Title()) < strings.ToLower(idx.recipes[j].ast.Title())
+	})
+
+	for _, src := range idx.recipes {
+		for _, tag := range tags(src) {
+			addToGroup(idx.tags, tag, src)
+		}
+		if c := category(src); c != "" {
+			addToGroup(idx.categories, c, src)
+		}
+
+		seen := map[string]bool{}
+		for _, step := range src.ast.Recipe.Steps {
+			for _, ing := range step.Ingredients() {
+				k := ing.Key()
+				if k == "" || seen[k] {
+					continue
+				}
+				seen[k] = true
+
+				g, ok := idx.ingredients[k]
+				if !ok {
+					g = &group{name: strings.TrimSpace(ing.Name)}
+					idx.ingredients[k] = g
+				}
+				g.recipes = append(g.recipes, src)
+			}
+		}
+	}
+
+	taken := map[string]bool{}
+	for _, src := range idx.recipes {
+		taken[src.output] = true
+	}
+	assignSlugs(idx.tags, "tags/", "tag", taken)
+	assignSlugs(idx.categories, "categories/", "category", taken)
+	// Ingredients are anchors on a single page rather than pages.
+	assignSlugs(idx.ingredients, "", "ingredient", map[string]bool{})
+
+	lang := strings.ToLower(s.Options.Locale)
+	lang, _, _ = strings.Cut(lang, "-")
+	idx.data = siteData{
+		Title:         s.Title,
+		Lang:          lang,
+		Labels:        labels["en"],
+		HasTags:       len(idx.tags) != 0,
+		HasCategories: len(idx.categories) != 0,
+	}
+	if l, ok := labels[lang]; ok {
+		idx.data.Labels = l
+	}
+	if idx.data.Lang == "" {
+		idx.data.Lang = "en"
+	}
+
+	return idx
+}
+
+func addToGroup(groups map[string]*group, name string, src *source) {
+	k := strings.ToLower(name)
+	g, ok := groups[k]
+	if !ok {
+		g = &group{name: name}
+		groups[k] = g
+	}
+	g.recipes = append(g.recipes, src)
+}
+
+// assignSlugs gives the groups unique slugs, so that tags such as
+// "Side dish" and "side-dish" get pages of their own. Slugs are
+// numbered when they are taken, and a name without letters or digits
+// gets the slug fallback. The page of a group is named by prefix
+// followed by the slug, and is added to taken.
+func assignSlugs(groups map[string]*group, prefix, fallback string, taken map[string]bool) {
+	for _, g := range sortedGroups(groups) {
+		base := slug(g.name)
+		if base == "" {
+			base = fallback
+		}
+		g.slug = base
+		for i := 2; taken[prefix+g.slug+".html"] || generated[prefix+g.slug+".html"]; i++ {
+			g.slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[prefix+g.slug+".html"] = true
+	}
+}
+
+func tags(src *source) []string {
+	var list []string
+	for _, md := range src.ast.Recipe.Metadata {
+		if !strings.EqualFold(strings.TrimSpace(md.Key), "tags") {
+			continue
+		}
+		for _, t := range strings.Split(md.Value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				list = append(list, t)
+			}
+		}
+	}
+	return list
+}
+
+// category returns the "category" of the recipe, or its "course" if it
+// has no category.
+func category(src *source) string {
+	for _, key := range []string{"category", "course"} {
+		for _, md := range src.ast.Recipe.Metadata {
+			if v := strings.TrimSpace(md.Value); v != "" && strings.EqualFold(strings.TrimSpace(md.Key), key) {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// slug turns a name into something that can safely be used as a file
+// name.
+func slug(name string) string {
+	b := strings.Builder{}
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() != 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// relative returns the link from the page from to the page to, both
+// relative to the root of the site.
+func relative(from, to string) string {
+	return strings.Repeat("../", strings.Count(from, "/")) + to
+}
+
+func (idx *index) links(from string, recipes []*source) []link {
+	links := make([]link, 0, len(recipes))
+	for _, src := range recipes {
+		links = append(links, link{Title: src.ast.Title(), Link: relative(from, src.output)})
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		return strings.ToLower(links[i].Title) < strings.ToLower(links[j].Title)
+	})
+	return links
+}
+
+// page renders a page of the site and writes it with idx.write.
+func (idx *index) page(name string, content string, data pageData) error {
+	b, err := renderPage(name, content, data)
+	if err != nil {
+		return err
+	}
+	return idx.write(name, b)
+}
+
+func (idx *index) recipePage(src *source, opts renderer.Options) (pageData, error) {
+	body := bytes.Buffer{}
+	if err := (renderer.HTML{AST: src.ast}).RenderTo(&body, opts); err != nil {
+		return pageData{}, err
+	}
+	head, err := renderer.JSONLD{AST: src.ast}.Script(opts)
+	if err != nil {
+		return pageData{}, err
+	}
+
+	ast := opts.Apply(src.ast)
+	r := &recipeData{
+		Title: ast.Title(),
+		Body:  template.HTML(body.String()),
+	}
+
+	for _, md := range ast.Recipe.Metadata {
+		switch strings.ToLower(md.Key) {
+		case "title", "tags":
+			continue
+		}
+		m := metadataData{Key: md.Key, Value: md.Value}
+		if u, err := url.Parse(md.Value); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			m.Link = md.Value
+		}
+		r.Metadata = append(r.Metadata, m)
+	}
+
+	for _, t := range tags(src) {
+		r.Tags = append(r.Tags, link{Name: t, Link: relative(src.output, "tags/"+idx.tags[strings.ToLower(t)].slug+".html")})
+	}
+
+	list := &ingredients.List{}
+	list.AddRecipe(renderer.Options{Scale: opts.Scale, Units: opts.Units}.Apply(src.ast), 1)
+	for _, item := range list.Items() {
+		r.Ingredients = append(r.Ingredients, ingredientData{Name: item.Name, Quantity: item.Quantity()})
+	}
+
+	return pageData{
+		Site:   idx.data,
+		Title:  r.Title,
+		Head:   head,
+		Recipe: r,
+	}, nil
+}
+
+// groupPages returns the names of the pages that renderIndexes writes
+// for the tags and categories.
+func (idx *index) groupPages() []string {
+	var names []string
+	for dir, groups := range map[string]map[string]*group{"tags": idx.tags, "categories": idx.categories} {
+		if len(groups) == 0 {
+			continue
+		}
+		names = append(names, dir+"/index.html")
+		for _, g := range groups {
+			names = append(names, dir+"/"+g.slug+".html")
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (idx *index) renderIndexes() error {
+	err := idx.page("index.html", "index", pageData{
+		Site:    idx.data,
+		Title:   idx.data.Labels["recipes"],
+		Recipes: idx.links("index.html", idx.recipes),
+	})
+	if err != nil {
+		return err
+	}
+
+	for dir, groups := range map[string]map[string]*group{"tags": idx.tags, "categories": idx.categories} {
+		if len(groups) == 0 {
+			continue
+		}
+
+		list := pageData{Site: idx.data, Title: idx.data.Labels[dir]}
+		for _, g := range sortedGroups(groups) {
+			name := dir + "/" + g.slug + ".html"
+			list.Groups = append(list.Groups, groupData{
+				Name:    g.name,
+				Link:    relative(dir+"/index.html", name),
+				Recipes: idx.links(dir+"/index.html", g.recipes),
+			})
+
+			err := idx.page(name, "index", pageData{
+				Site:    idx.data,
+				Title:   g.name,
+				Recipes: idx.links(name, g.recipes),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := idx.page(dir+"/index.html", "groups", list); err != nil {
+			return err
+		}
+	}
+
+	ing := pageData{Site: idx.data, Title: idx.data.Labels["ingredients"]}
+	for _, g := range sortedGroups(idx.ingredients) {
+		ing.Groups = append(ing.Groups, groupData{
+			Name:    g.name,
+			Slug:    g.slug,
+			Recipes: idx.links("ingredients.html", g.recipes),
+		})
+	}
+	return idx.page("ingredients.html", "ingredient-index", ing)
+}
+
+func sortedGroups(groups map[string]*group) []*group {
+	list := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].name) < strings.ToLower(list[j].name)
+	})
+	return list
+}
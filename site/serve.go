@@ -0,0 +1,243 @@
+package site
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/dememorized/cook/internal/conversion"
+	"github.com/dememorized/cook/search"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// VersionPath is the path, relative to the root of a served site, that
+// returns a fingerprint of the sources. Served pages poll it and reload
+// when it changes.
+const VersionPath = "_version"
+
+// Handler serves the site straight from its sources without writing
+// anything to the output directory. Recipe pages are rendered for
+// every request, which allows them to be scaled and converted with
+// the scale, servings and units query parameters. The indexes are
+// rebuilt whenever a source changes, and a search page finds recipes
+// with a [search.Index].
+func (s *Site) Handler() http.Handler {
+	s.defaults()
+	return &handler{site: s}
+}
+
+type handler struct {
+	site *Site
+
+	mu    sync.Mutex
+	state *served
+}
+
+// served is the site as built from one version of the sources.
+type served struct {
+	version string
+	idx     *index
+	pages   map[string][]byte
+	sources map[string]*source
+	errs    map[string]error
+	index   *search.Index
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+
+	if name == VersionPath {
+		version, err := h.version()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, version)
+		return
+	}
+	if name == "style.css" {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write(h.site.css())
+		return
+	}
+
+	st, err := h.load()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var b []byte
+	switch {
+	case name == "search.html":
+		b, err = st.search(r.URL.Query().Get("q"))
+	case st.sources[name] != nil:
+		b, err = st.recipe(st.sources[name], r.URL.Query())
+	case st.errs[name] != nil:
+		err = st.errs[name]
+	case st.pages[name] != nil:
+		b = st.pages[name]
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(b)
+}
+
+// version returns a fingerprint of the names, sizes and modification
+// times of the sources.
+func (h *handler) version() (string, error) {
+	names, err := sourceNames(h.site.Source)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, name := range names {
+		info, err := fs.Stat(h.site.Source, name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
+
+// load returns the site built from the current sources, rebuilding it
+// if they have changed since the last request.
+func (h *handler) load() (*served, error) {
+	version, err := h.version()
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.state != nil && h.state.version == version {
+		return h.state, nil
+	}
+
+	names, err := sourceNames(h.site.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &served{
+		version: version,
+		pages:   map[string][]byte{},
+		sources: map[string]*source{},
+		errs:    map[string]error{},
+		index:   search.New(nil),
+	}
+	sources := h.site.parse(names)
+	assignOutputs(sources)
+
+	var recipes []*source
+	for _, src := range sources {
+		if src.err != nil {
+			st.errs[src.output] = src.err
+			continue
+		}
+		recipes = append(recipes, src)
+		st.sources[src.output] = src
+		st.index.Add(src.name, src.ast)
+	}
+
+	st.idx = newIndex(h.site, recipes)
+	st.idx.data.Dynamic = true
+	st.idx.data.Version = version
+	st.idx.write = func(name string, b []byte) error {
+		st.pages[name] = b
+		return nil
+	}
+	if err := st.idx.renderIndexes(); err != nil {
+		return nil, err
+	}
+
+	h.state = st
+	return st, nil
+}
+
+func (st *served) recipe(src *source, query url.Values) ([]byte, error) {
+	opts := st.idx.site.Options
+	form := &optionsData{
+		Scale:   "1",
+		Units:   opts.Units.String(),
+		Systems: []string{"any", "metric", "imperial"},
+	}
+
+	if v := query.Get("units"); v != "" {
+		units, err := conversion.ParseSystem(v)
+		if err != nil {
+			return nil, err
+		}
+		opts.Units = units
+		form.Units = units.String()
+	}
+	if v := query.Get("scale"); v != "" {
+		scale, err := conversion.Numeral(v).Float()
+		if err != nil || scale <= 0 {
+			return nil, fmt.Errorf("invalid scale '%s'", v)
+		}
+		opts.Scale = scale
+		form.Scale = v
+	}
+	if v := query.Get("servings"); v != "" {
+		servings, err := strconv.ParseFloat(v, 64)
+		have, ok := src.ast.Servings()
+		if err != nil || servings <= 0 || !ok {
+			return nil, fmt.Errorf("can't scale %s to '%s' servings", src.name, v)
+		}
+		opts.Scale = servings / have
+		form.Scale = conversion.FormatFloat(opts.Scale)
+	}
+
+	page, err := st.idx.recipePage(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	page.Options = form
+	return renderPage(src.output, "recipe", page)
+}
+
+// search lists the recipes matching the query, best matches first,
+// see [search.Index.Search].
+func (st *served) search(query string) ([]byte, error) {
+	var matches []link
+	if strings.TrimSpace(query) != "" {
+		// A query that can't be parsed, such as one with an
+		// unterminated quote while typing, finds nothing.
+		results, _ := st.index.Search(query)
+		outputs := map[string]string{}
+		for _, src := range st.idx.recipes {
+			outputs[src.name] = src.output
+		}
+		for _, r := range results {
+			matches = append(matches, link{Title: r.Title, Link: outputs[r.Path]})
+		}
+	}
+
+	return renderPage("search.html", "index", pageData{
+		Site:    st.idx.data,
+		Title:   st.idx.data.Labels["search"],
+		Query:   query,
+		Recipes: matches,
+	})
+}
@@ -0,0 +1,71 @@
+package site
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func get(t *testing.T, h http.Handler, target string) (int, string) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	b, err := io.ReadAll(w.Result().Body)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return w.Code, string(b)
+}
+
+func TestHandler(t *testing.T) {
+	fsys := readTestdata(t)
+	h := (&Site{Source: fsys}).Handler()
+
+	code, index := get(t, h, "/")
+	if code != http.StatusOK || !strings.Contains(index, `<a href="breakfast/pancakes.html">pancakes</a>`) ||
+		!strings.Contains(index, `action="search.html"`) {
+		t.Errorf("expected an index with search, got %d:\n%s", code, index)
+	}
+
+	code, pancakes := get(t, h, "/breakfast/pancakes.html?scale=2&units=imperial")
+	for _, expected := range []string{
+		`<span class="cook-quantity">`,
+		`<input type="number" name="scale" min="0.25" step="any" value="2">`,
+		`<option value="imperial" selected>Imperial</option>`,
+		`fetch("..\/_version"`,
+	} {
+		if code != http.StatusOK || !strings.Contains(pancakes, expected) {
+			t.Errorf("expected recipe page to contain %s, got %d:\n%s", expected, code, pancakes)
+		}
+	}
+	if strings.Contains(pancakes, "125 g") {
+		t.Errorf("expected flour to be scaled and converted, got:\n%s", pancakes)
+	}
+
+	code, search := get(t, h, "/search.html?q=TOMATO")
+	if code != http.StatusOK || !strings.Contains(search, `<a href="soup.html">Tomato soup</a>`) || strings.Contains(search, "pancakes.html") {
+		t.Errorf("expected search to find only the soup, got %d:\n%s", code, search)
+	}
+
+	_, search = get(t, h, "/search.html?q=ingredient:milk+tag:vegetarian")
+	if !strings.Contains(search, "soup.html") || strings.Contains(search, "pancakes.html") {
+		t.Errorf("expected search filters to find only the soup, got:\n%s", search)
+	}
+
+	if code, _ := get(t, h, "/missing.html"); code != http.StatusNotFound {
+		t.Errorf("expected missing page to not be found, got %d", code)
+	}
+
+	_, before := get(t, h, "/"+VersionPath)
+	fsys["broken.aroma"] = &fstest.MapFile{Data: []byte(`(recipe {"servings" 2}`)}
+	_, after := get(t, h, "/"+VersionPath)
+	if before == after {
+		t.Errorf("expected version to change with the sources, got %s", after)
+	}
+	if code, body := get(t, h, "/broken.html"); code != http.StatusInternalServerError {
+		t.Errorf("expected broken recipe to be an error, got %d: %s", code, body)
+	}
+}
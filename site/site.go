@@ -0,0 +1,397 @@
+// Package site generates a static website from a directory of
+// recipes, with a page per recipe, an index sorted by title, pages for
+// every tag and category, and an index of ingredients.
+package site
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/load"
+	"github.com/dememorized/cook/renderer"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ManifestName is the name of the file in the output directory that
+// keeps track of which sources the pages were built from.
+const ManifestName = ".cook-manifest.json"
+
+//go:embed templates.html
+var templatesRaw string
+var templates = template.Must(template.New("site").Parse(templatesRaw))
+
+//go:embed style.css
+var styleCSS []byte
+
+// Site is a static website built from the recipes in Source.
+type Site struct {
+	// Source contains the recipes. Every .cook and .aroma file in it
+	// becomes a page.
+	Source fs.FS
+	// Output is the directory the website is written to.
+	Output string
+	// Title is the name of the website. Defaults to "Recipes".
+	Title string
+	// Workers is the number of recipes that are parsed and rendered
+	// at the same time. Defaults to the number of CPUs.
+	Workers int
+	// Options is used when rendering the recipes.
+	Options renderer.Options
+	// Force rebuilds every page, even if its source hasn't changed.
+	Force bool
+	// CSS replaces the default stylesheet.
+	CSS []byte
+}
+
+// Report summarizes a build.
+type Report struct {
+	// Rendered are the sources whose pages were rebuilt.
+	Rendered []string
+	// Unchanged is the number of pages that were up to date.
+	Unchanged int
+	// Removed are the pages of sources, tags and categories that no
+	// longer exist.
+	Removed []string
+	// Errors are the sources that couldn't be built. They don't stop
+	// the rest of the site from being built.
+	Errors []error
+}
+
+type manifest struct {
+	// Config is the fingerprint of the settings and the index that
+	// every page was rendered with.
+	Config  string                   `json:"config"`
+	Sources map[string]manifestEntry `json:"sources"`
+	// Pages are the pages of the tags and categories.
+	Pages []string `json:"pages"`
+}
+
+type manifestEntry struct {
+	Hash   string `json:"hash"`
+	Output string `json:"output"`
+}
+
+type source struct {
+	name   string
+	hash   string
+	ast    *aromalang.AST
+	output string
+	err    error
+}
+
+// Build writes the website to the output directory. Pages are only
+// rendered for recipes whose source has changed since the last build,
+// while the index pages are always rebuilt. Every page is rendered again
+// when the title, options, stylesheet or tags of the site change.
+func (s *Site) Build() (Report, error) {
+	report := Report{}
+	s.defaults()
+
+	names, err := sourceNames(s.Source)
+	if err != nil {
+		return report, err
+	}
+
+	old := s.readManifest()
+	sources := s.parse(names)
+	assignOutputs(sources)
+
+	var recipes []*source
+	for _, src := range sources {
+		if src.err != nil {
+			report.Errors = append(report.Errors, src.err)
+			continue
+		}
+		recipes = append(recipes, src)
+	}
+
+	idx := newIndex(s, recipes)
+	next := manifest{Config: idx.fingerprint(), Sources: map[string]manifestEntry{}}
+	force := s.Force || next.Config != old.Config
+
+	var changed []*source
+	for _, src := range recipes {
+		next.Sources[src.name] = manifestEntry{Hash: src.hash, Output: src.output}
+
+		prev, ok := old.Sources[src.name]
+		if !force && ok && prev.Hash == src.hash && prev.Output == src.output && s.exists(src.output) {
+			report.Unchanged++
+			continue
+		}
+		changed = append(changed, src)
+	}
+
+	outputs := map[string]bool{}
+	for _, entry := range next.Sources {
+		outputs[entry.Output] = true
+	}
+	for _, entry := range old.Sources {
+		if outputs[entry.Output] {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.Output, filepath.FromSlash(entry.Output)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, err
+		}
+		report.Removed = append(report.Removed, entry.Output)
+	}
+
+	for _, err := range s.renderRecipes(idx, changed) {
+		report.Errors = append(report.Errors, err)
+	}
+	for _, src := range changed {
+		report.Rendered = append(report.Rendered, src.name)
+	}
+	sort.Strings(report.Rendered)
+
+	if err := idx.renderIndexes(); err != nil {
+		return report, err
+	}
+	next.Pages = idx.groupPages()
+	for _, name := range next.Pages {
+		outputs[name] = true
+	}
+	for _, name := range old.Pages {
+		if outputs[name] {
+			continue
+		}
+		err := os.Remove(filepath.Join(s.Output, filepath.FromSlash(name)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, err
+		}
+		report.Removed = append(report.Removed, name)
+	}
+	sort.Strings(report.Removed)
+
+	if err := s.write("style.css", s.css()); err != nil {
+		return report, err
+	}
+
+	return report, s.writeManifest(next)
+}
+
+func (s *Site) defaults() {
+	if s.Title == "" {
+		s.Title = "Recipes"
+	}
+	if s.Workers <= 0 {
+		s.Workers = runtime.NumCPU()
+	}
+}
+
+func (s *Site) css() []byte {
+	if s.CSS == nil {
+		return styleCSS
+	}
+	return s.CSS
+}
+
+// fingerprint returns a hash of what the recipe pages depend on besides
+// their own source, which is the settings of the site, whether it has
+// tags and categories, and the pages of the tags.
+func (idx *index) fingerprint() string {
+	slugs := map[string]string{}
+	for k, g := range idx.tags {
+		slugs[k] = g.slug
+	}
+	css := sha256.Sum256(idx.site.css())
+
+	// Maps are encoded with sorted keys, so the result is stable.
+	b, _ := json.Marshal(struct {
+		Title         string
+		Options       renderer.Options
+		CSS           string
+		HasTags       bool
+		HasCategories bool
+		Tags          map[string]string
+	}{
+		Title:         idx.site.Title,
+		Options:       idx.site.Options,
+		CSS:           hex.EncodeToString(css[:]),
+		HasTags:       idx.data.HasTags,
+		HasCategories: idx.data.HasCategories,
+		Tags:          slugs,
+	})
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func sourceNames(fsys fs.FS) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != "." && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && load.Supported(p) {
+			names = append(names, p)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// parse reads and parses the sources with s.Workers workers. The
+// names of their pages are set by [assignOutputs].
+func (s *Site) parse(names []string) []*source {
+	sources := make([]*source, len(names))
+	s.each(len(names), func(i int) {
+		src := &source{name: names[i]}
+		sources[i] = src
+
+		b, err := fs.ReadFile(s.Source, src.name)
+		if err != nil {
+			src.err = err
+			return
+		}
+		h := sha256.Sum256(b)
+		src.hash = hex.EncodeToString(h[:])
+
+		src.ast, src.err = load.Parse(src.name, bytes.NewReader(b))
+	})
+	return sources
+}
+
+// outputName returns the name of the page for the source called name.
+func outputName(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + ".html"
+}
+
+// generated are the pages of the site that aren't recipes.
+var generated = map[string]bool{
+	"index.html":            true,
+	"ingredients.html":      true,
+	"search.html":           true,
+	"tags/index.html":       true,
+	"categories/index.html": true,
+}
+
+// assignOutputs gives the sources unique page names. A source whose
+// page would replace another page, such as pancakes.cook next to
+// pancakes.aroma or an index.cook, keeps its extension in the name of
+// its page instead, as in pancakes.cook.html.
+func assignOutputs(sources []*source) {
+	taken := map[string]bool{}
+	for _, src := range sources {
+		name := outputName(src.name)
+		if taken[name] || generated[name] {
+			name = src.name + ".html"
+		}
+		for i := 2; taken[name] || generated[name]; i++ {
+			name = fmt.Sprintf("%s-%d.html", src.name, i)
+		}
+		taken[name] = true
+		src.output = name
+	}
+}
+
+// each calls fn for every index up to n using at most s.Workers
+// goroutines at a time.
+func (s *Site) each(n int, fn func(i int)) {
+	work := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < s.Workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+}
+
+func (s *Site) renderRecipes(idx *index, sources []*source) []error {
+	errs := make([]error, len(sources))
+	s.each(len(sources), func(i int) {
+		src := sources[i]
+		page, err := idx.recipePage(src, s.Options)
+		if err == nil {
+			err = idx.page(src.output, "recipe", page)
+		}
+		if err != nil {
+			errs[i] = fmt.Errorf("%s: %w", src.name, err)
+		}
+	})
+
+	var res []error
+	for _, err := range errs {
+		if err != nil {
+			res = append(res, err)
+		}
+	}
+	return res
+}
+
+// renderPage executes the named template within the site's layout for
+// the page called name.
+func renderPage(name string, content string, data pageData) ([]byte, error) {
+	tmpl, err := templates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err = tmpl.New("content").Parse(`{{ template "` + content + `" . }}`)
+	if err != nil {
+		return nil, err
+	}
+
+	data.Root = strings.Repeat("../", strings.Count(name, "/"))
+	buf := bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Site) write(name string, b []byte) error {
+	p := filepath.Join(s.Output, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o644)
+}
+
+func (s *Site) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(s.Output, filepath.FromSlash(name)))
+	return err == nil
+}
+
+func (s *Site) readManifest() manifest {
+	m := manifest{Sources: map[string]manifestEntry{}}
+	b, err := os.ReadFile(filepath.Join(s.Output, ManifestName))
+	if err != nil {
+		return m
+	}
+	// A broken manifest only means that everything is rebuilt.
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+func (s *Site) writeManifest(m manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return s.write(ManifestName, b)
+}
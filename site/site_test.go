@@ -0,0 +1,248 @@
+package site
+
+import (
+	"github.com/dememorized/cook/aromalang"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func readTestdata(t *testing.T) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range []string{"breakfast/pancakes.cook", "soup.aroma"} {
+		b, err := os.ReadFile(filepath.Join("testdata", "recipes", filepath.FromSlash(name)))
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		fsys[name] = &fstest.MapFile{Data: b}
+	}
+	return fsys
+}
+
+func readOutput(t *testing.T, dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return string(b)
+}
+
+func TestBuild(t *testing.T) {
+	fsys := readTestdata(t)
+	out := t.TempDir()
+	s := &Site{Source: fsys, Output: out, Title: "My recipes"}
+
+	report, err := s.Build()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(report.Errors) != 0 || len(report.Rendered) != 2 {
+		t.Errorf("expected two rendered recipes without errors, got %+v", report)
+	}
+
+	for _, name := range []string{
+		"index.html",
+		"style.css",
+		"ingredients.html",
+		"breakfast/pancakes.html",
+		"soup.html",
+		"tags/index.html",
+		"tags/quick.html",
+		"tags/vegetarian.html",
+		"categories/index.html",
+		"categories/breakfast.html",
+		ManifestName,
+	} {
+		readOutput(t, out, name)
+	}
+
+	index := readOutput(t, out, "index.html")
+	if !strings.Contains(index, `<a href="breakfast/pancakes.html">pancakes</a>`) ||
+		strings.Index(index, "pancakes") > strings.Index(index, "Tomato soup") {
+		t.Errorf("expected sorted links to recipes in index, got:\n%s", index)
+	}
+
+	pancakes := readOutput(t, out, "breakfast/pancakes.html")
+	for _, expected := range []string{
+		`href="../style.css"`,
+		`<a href="../tags/sweet.html">sweet</a>`,
+		`<span class="cook-quantity">125 g</span> flour`,
+		`application/ld+json`,
+	} {
+		if !strings.Contains(pancakes, expected) {
+			t.Errorf("expected recipe page to contain %s, got:\n%s", expected, pancakes)
+		}
+	}
+
+	quick := readOutput(t, out, "tags/quick.html")
+	if !strings.Contains(quick, `href="../soup.html"`) || !strings.Contains(quick, `href="../breakfast/pancakes.html"`) {
+		t.Errorf("expected both recipes to be tagged quick, got:\n%s", quick)
+	}
+
+	ingredients := readOutput(t, out, "ingredients.html")
+	if !strings.Contains(ingredients, `<dt id="milk">milk</dt>`) {
+		t.Errorf("expected milk in the ingredient index, got:\n%s", ingredients)
+	}
+}
+
+func TestBuildIncremental(t *testing.T) {
+	fsys := readTestdata(t)
+	out := t.TempDir()
+	s := &Site{Source: fsys, Output: out}
+
+	_, err := s.Build()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	report, err := s.Build()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(report.Rendered) != 0 || report.Unchanged != 2 {
+		t.Errorf("expected nothing to be rebuilt, got %+v", report)
+	}
+
+	fsys["soup.aroma"].Data = []byte(strings.Replace(string(fsys["soup.aroma"].Data), "Tomato soup", "Tomato bisque", 1))
+	delete(fsys, "breakfast/pancakes.cook")
+	fsys["broken.aroma"] = &fstest.MapFile{Data: []byte("(recipe {")}
+
+	report, err = s.Build()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(report.Rendered) != 1 || report.Rendered[0] != "soup.aroma" {
+		t.Errorf("expected soup to be rebuilt, got %+v", report.Rendered)
+	}
+	if strings.Join(report.Removed, " ") != "breakfast/pancakes.html categories/breakfast.html tags/sweet.html" {
+		t.Errorf("expected pancakes and the pages of its tag and category to be removed, got %+v", report.Removed)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("expected an error for the broken recipe, got %+v", report.Errors)
+	}
+	for _, name := range []string{"breakfast/pancakes.html", "tags/sweet.html", "categories/breakfast.html"} {
+		if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(name))); err == nil {
+			t.Errorf("expected %s to be deleted", name)
+		}
+	}
+	if !strings.Contains(readOutput(t, out, "categories/index.html"), "Dinner") {
+		t.Error("expected the remaining category to be kept")
+	}
+	if !strings.Contains(readOutput(t, out, "soup.html"), "Tomato bisque") {
+		t.Error("expected the soup page to be updated")
+	}
+}
+
+func TestBuildConfigChanges(t *testing.T) {
+	fsys := readTestdata(t)
+	out := t.TempDir()
+	s := &Site{Source: fsys, Output: out}
+
+	build := func(expected int) {
+		t.Helper()
+		report, err := s.Build()
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if len(report.Rendered) != expected {
+			t.Errorf("expected %d recipes to be rendered, got %+v", expected, report)
+		}
+	}
+
+	build(2)
+	build(0)
+
+	s.Title = "Dinners"
+	build(2)
+	if !strings.Contains(readOutput(t, out, "breakfast/pancakes.html"), "Dinners") {
+		t.Error("expected the unchanged recipe to get the new title")
+	}
+
+	s.Options.Locale = "sv"
+	build(2)
+	s.CSS = []byte("body {}")
+	build(2)
+	build(0)
+
+	// A new tag can change the pages that the tags of other recipes
+	// link to.
+	fsys["soup.aroma"].Data = []byte(strings.Replace(string(fsys["soup.aroma"].Data), "quick", "quick, !sweet", 1))
+	build(2)
+	if !strings.Contains(readOutput(t, out, "breakfast/pancakes.html"), `<a href="../tags/sweet-2.html">sweet</a>`) {
+		t.Error("expected the unchanged recipe to link to the new page of its tag")
+	}
+}
+
+func TestBuildCollisions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pancakes.aroma":  {Data: []byte(`(recipe {"title" "Aroma pancakes"} [(step {} [(instruction "Fry.")])])`)},
+		"pancakes.cook":   {Data: []byte(">> title: Cooklang pancakes\n\nFry.\n")},
+		"index.cook":      {Data: []byte(">> title: Index\n>> tags: Side dish, side-dish, !!!, quick\n\nServe.\n")},
+		"tags/quick.cook": {Data: []byte(">> title: Quick\n\nEat.\n")},
+	}
+	out := t.TempDir()
+	s := &Site{Source: fsys, Output: out}
+
+	report, err := s.Build()
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(report.Errors) != 0 || len(report.Rendered) != 4 {
+		t.Errorf("expected four rendered recipes without errors, got %+v", report)
+	}
+
+	for name, expected := range map[string]string{
+		"pancakes.html":         "Aroma pancakes",
+		"pancakes.cook.html":    "Cooklang pancakes",
+		"index.cook.html":       "Index",
+		"tags/quick.html":       "Quick",
+		"index.html":            `href="index.cook.html"`,
+		"tags/quick-2.html":     `href="../index.cook.html"`,
+		"tags/side-dish.html":   `href="../index.cook.html"`,
+		"tags/side-dish-2.html": `href="../index.cook.html"`,
+		"tags/tag.html":         `href="../index.cook.html"`,
+	} {
+		if page := readOutput(t, out, name); !strings.Contains(page, expected) {
+			t.Errorf("expected %s to contain %s, got:\n%s", name, expected, page)
+		}
+	}
+
+	index := readOutput(t, out, "index.cook.html")
+	for _, expected := range []string{
+		`<a href="tags/side-dish.html">Side dish</a>`,
+		`<a href="tags/side-dish-2.html">side-dish</a>`,
+		`<a href="tags/tag.html">!!!</a>`,
+		`<a href="tags/quick-2.html">quick</a>`,
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("expected the recipe to link to its tags with %s, got:\n%s", expected, index)
+		}
+	}
+}
+
+func TestCategory(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		src := &source{ast: &aromalang.AST{Recipe: aromalang.Recipe{Metadata: []aromalang.Metadata{
+			{Key: "course", Value: "Dinner"},
+			{Key: "Category", Value: " Soups "},
+			{Key: "tags", Value: "b, a"},
+			{Key: "Tags", Value: "c"},
+		}}}}
+		if c := category(src); c != "Soups" {
+			t.Errorf("expected the category before the course, got %q", c)
+		}
+		if list := strings.Join(tags(src), ","); list != "b,a,c" {
+			t.Errorf("expected the tags in order, got %s", list)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package substitution suggests replacements for ingredients that are
+// unavailable or can't be eaten, and rewrites recipes to use them with
+// the quantities scaled to the amounts in the recipe.
+package substitution
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/dememorized/cook/canonical"
+	"github.com/dememorized/cook/internal/conversion"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Part is an ingredient on either side of a substitution rule.
+type Part struct {
+	Name string
+	// Quantity is nil if the rule doesn't give an amount, in which case
+	// the amount of the replaced ingredient is used.
+	Quantity *conversion.Quantity
+}
+
+func (p Part) String() string {
+	if p.Quantity == nil {
+		return p.Name
+	}
+	return p.Quantity.String() + " " + p.Name
+}
+
+// Rule replaces an amount of an ingredient with one or more other
+// ingredients.
+type Rule struct {
+	From Part
+	To   []Part
+}
+
+func (r Rule) String() string {
+	to := make([]string, 0, len(r.To))
+	for _, p := range r.To {
+		to = append(to, p.String())
+	}
+	return r.From.String() + " = " + strings.Join(to, " + ")
+}
+
+// Database is a set of substitution rules keyed by the name of the
+// ingredient they replace.
+type Database struct {
+	rules map[string][]Rule
+	order []string
+	names *canonical.Dictionary
+}
+
+func NewDatabase(rules ...Rule) *Database {
+	db := &Database{rules: map[string][]Rule{}}
+	for _, r := range rules {
+		db.Add(r)
+	}
+	return db
+}
+
+// Add adds a rule to the database. Rules added earlier for the same
+// ingredient are preferred.
+func (db *Database) Add(r Rule) {
+	k := db.names.Key(r.From.Name)
+	if _, ok := db.rules[k]; !ok {
+		db.order = append(db.order, k)
+	}
+	db.rules[k] = append(db.rules[k], r)
+}
+
+// UseDictionary matches ingredient names using the synonyms in d.
+func (db *Database) UseDictionary(d *canonical.Dictionary) {
+	var rules []Rule
+	for _, k := range db.order {
+		rules = append(rules, db.rules[k]...)
+	}
+	db.rules = map[string][]Rule{}
+	db.order = nil
+	db.names = d
+	for _, r := range rules {
+		db.Add(r)
+	}
+}
+
+// Lookup returns the rules that replace the ingredient called name, in
+// order of preference.
+func (db *Database) Lookup(name string) []Rule {
+	return db.rules[db.names.Key(name)]
+}
+
+func (db *Database) Len() int {
+	n := 0
+	for _, rules := range db.rules {
+		n += len(rules)
+	}
+	return n
+}
+
+// Load reads substitution rules with one rule per line. The ingredient
+// to replace is on the left of an equals sign and its replacements,
+// separated by plus signs, on the right:
+//
+//	1 cup buttermilk = 1 cup milk + 1 tbsp lemon juice
+//	1 egg = 1 tbsp ground flaxseed + 3 tbsp water
+//	butter = margarine
+//
+// Amounts are written as a number followed by an optional unit. A rule
+// without amounts replaces an ingredient with the same amount of
+// another. Empty lines and lines starting with # are ignored.
+func Load(r io.Reader) (*Database, error) {
+	db := NewDatabase()
+
+	s := bufio.NewScanner(r)
+	for lineNo := 1; s.Scan(); lineNo++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		from, to, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected '=' between an ingredient and its substitutes", lineNo)
+		}
+
+		rule := Rule{}
+		var err error
+		rule.From, err = parsePart(from)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		for _, t := range strings.Split(to, "+") {
+			p, err := parsePart(t)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			rule.To = append(rule.To, p)
+		}
+
+		if rule.From.Quantity == nil {
+			for _, p := range rule.To {
+				if p.Quantity != nil {
+					return nil, fmt.Errorf("line %d: %s needs an amount to be replaced by %s", lineNo, rule.From.Name, p)
+				}
+			}
+		}
+		db.Add(rule)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// parsePart reads an ingredient with an optional amount, such as
+// "1 tbsp lemon juice", "1 1/2 cup cream", "3 eggs" or "margarine". A
+// word after the number is only read as a unit if it is a known one.
+func parsePart(s string) (Part, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Part{}, fmt.Errorf("missing ingredient")
+	}
+
+	// A mixed number such as "1 1/2" spans two fields.
+	number := 1
+	if len(fields) > 1 && strings.Contains(fields[1], "/") {
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			number = 2
+		}
+	}
+
+	v, err := conversion.Numeral(strings.Join(fields[:number], " ")).Float()
+	if err != nil {
+		return Part{Name: strings.Join(fields, " ")}, nil
+	}
+	if v <= 0 {
+		return Part{}, fmt.Errorf("amount of %s must be positive", strings.Join(fields[number:], " "))
+	}
+
+	q := conversion.Quantity{Value: v}
+	q.Unit, _ = conversion.LookupUnit("")
+	name := fields[number:]
+	if len(name) != 0 {
+		if u, ok := conversion.LookupUnit(name[0]); ok {
+			q.Unit = u
+			name = name[1:]
+		}
+	}
+	if len(name) == 0 {
+		return Part{}, fmt.Errorf("missing ingredient after '%s'", strings.Join(fields, " "))
+	}
+	return Part{Name: strings.Join(name, " "), Quantity: &q}, nil
+}
@@ -0,0 +1,243 @@
+package substitution
+
+import (
+	"fmt"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/conversion"
+	"strings"
+)
+
+// Suggestion is a way to replace an ingredient of a recipe.
+type Suggestion struct {
+	// Step is the index of the step the ingredient is used in.
+	Step int
+	// Ingredient is the ingredient to replace, as it is written in the
+	// recipe.
+	Ingredient aromalang.Ingredient
+	Rule       Rule
+	// Replacements are the ingredients of the rule with their
+	// quantities scaled to the amount of Ingredient.
+	Replacements []aromalang.Ingredient
+}
+
+func (s Suggestion) String() string {
+	to := make([]string, 0, len(s.Replacements))
+	for _, r := range s.Replacements {
+		to = append(to, amount(r))
+	}
+	return amount(s.Ingredient) + " → " + strings.Join(to, " + ")
+}
+
+func amount(ing aromalang.Ingredient) string {
+	return strings.Join(strings.Fields(ing.Quantity+" "+ing.Unit+" "+ing.Name), " ")
+}
+
+// Suggest returns the ways to replace every use of the ingredients in
+// avoid, in the order of the recipe and of preference. Rules whose
+// replacements are themselves in avoid aren't suggested, unless they
+// are kinds of the ingredient the rule replaces, nor are rules
+// whose amounts can't be converted to the amount in the recipe.
+func (db *Database) Suggest(ast *aromalang.AST, avoid []string) []Suggestion {
+	var res []Suggestion
+	for i, step := range ast.Recipe.Steps {
+		for _, ing := range step.Ingredients() {
+			if !db.avoided(ing.Name, avoid) {
+				continue
+			}
+			for _, rule := range db.rulesFor(ing.Name, avoid) {
+				if s, ok := db.suggest(ing, rule, avoid); ok {
+					s.Step = i
+					res = append(res, s)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// Rewrite returns a copy of the recipe with every use of the
+// ingredients in avoid replaced by the first suggestion for it, and
+// the suggestions that were used. The substitutions are noted in a
+// comment at the end of the line they are on, so that the lines of a
+// Cooklang recipe can be written back as they were. If some
+// ingredients can't be replaced,
+// they are left in the recipe and an error listing them is returned
+// together with the rewritten recipe.
+func (db *Database) Rewrite(ast *aromalang.AST, avoid []string) (*aromalang.AST, []Suggestion, error) {
+	res := *ast
+	res.Recipe.Steps = make([]aromalang.Step, len(ast.Recipe.Steps))
+
+	var applied []Suggestion
+	var missing []string
+	for i, step := range ast.Recipe.Steps {
+		comps := make([]aromalang.Component, 0, len(step.Components))
+		notes := map[int][]string{}
+		for _, c := range step.Components {
+			ing, ok := c.(aromalang.Ingredient)
+			if !ok || !db.avoided(ing.Name, avoid) {
+				comps = append(comps, c)
+				continue
+			}
+
+			s, ok := db.first(ing, avoid)
+			if !ok {
+				comps = append(comps, c)
+				missing = appendUnique(missing, ing.Name)
+				continue
+			}
+			s.Step = i
+			applied = append(applied, s)
+
+			for j, r := range s.Replacements {
+				switch {
+				case j == 0:
+				case j == len(s.Replacements)-1:
+					comps = append(comps, aromalang.Instruction{Base: ing.Base, Instruction: " and "})
+				default:
+					comps = append(comps, aromalang.Instruction{Base: ing.Base, Instruction: ", "})
+				}
+				comps = append(comps, r)
+			}
+			notes[ing.Pos.Line] = append(notes[ing.Pos.Line], amount(ing))
+		}
+		step.Components = noted(comps, notes)
+		res.Recipe.Steps[i] = step
+	}
+
+	if len(missing) != 0 {
+		return &res, applied, fmt.Errorf("no substitute for %s", strings.Join(missing, ", "))
+	}
+	return &res, applied, nil
+}
+
+// noted returns comps with a comment after the last component on each
+// of the lines in notes, listing what was replaced on that line.
+func noted(comps []aromalang.Component, notes map[int][]string) []aromalang.Component {
+	if len(notes) == 0 {
+		return comps
+	}
+
+	res := make([]aromalang.Component, 0, len(comps)+len(notes))
+	for i, c := range comps {
+		res = append(res, c)
+
+		line := c.Position().Line
+		if i+1 < len(comps) && comps[i+1].Position().Line == line {
+			continue
+		}
+		if n, ok := notes[line]; ok {
+			res = append(res, aromalang.Comment{
+				Base:    aromalang.Base{Pos: c.Position()},
+				Comment: "instead of " + strings.Join(n, " and "),
+			})
+			delete(notes, line)
+		}
+	}
+	return res
+}
+
+func (db *Database) first(ing aromalang.Ingredient, avoid []string) (Suggestion, bool) {
+	for _, rule := range db.rulesFor(ing.Name, avoid) {
+		if s, ok := db.suggest(ing, rule, avoid); ok {
+			return s, true
+		}
+	}
+	return Suggestion{}, false
+}
+
+// rulesFor returns the rules for the ingredient called name, followed
+// by the rules for the less specific ingredients in avoid that it is a
+// kind of, so that the rules for "milk" also replace "whole milk".
+func (db *Database) rulesFor(name string, avoid []string) []Rule {
+	rules := db.Lookup(name)
+	seen := map[string]bool{db.names.Key(name): true}
+	for _, a := range avoid {
+		k := db.names.Key(a)
+		if !seen[k] && db.avoided(name, []string{a}) {
+			seen[k] = true
+			rules = append(rules[:len(rules):len(rules)], db.Lookup(a)...)
+		}
+	}
+	return rules
+}
+
+// suggest scales the replacements of rule to the amount of ing.
+func (db *Database) suggest(ing aromalang.Ingredient, rule Rule, avoid []string) (Suggestion, bool) {
+	// A rule for milk may replace it with oat milk even though that is
+	// a kind of milk, but not with anything else that is avoided.
+	var others []string
+	for _, a := range avoid {
+		if !db.avoided(rule.From.Name, []string{a}) {
+			others = append(others, a)
+		}
+	}
+	for _, p := range rule.To {
+		if db.avoided(p.Name, others) {
+			return Suggestion{}, false
+		}
+	}
+
+	s := Suggestion{Ingredient: ing, Rule: rule}
+
+	// factor is how many times the rule's amount the recipe uses. It
+	// is zero if the ingredient isn't measured.
+	factor := 0.0
+	q, err := conversion.ParseQuantity(ing.Quantity, ing.Unit)
+	if err == nil && rule.From.Quantity != nil {
+		from, err := q.In(rule.From.Quantity.Unit)
+		if err != nil {
+			return Suggestion{}, false
+		}
+		factor = from.Value / rule.From.Quantity.Value
+	}
+
+	for _, p := range rule.To {
+		r := aromalang.Ingredient{Base: ing.Base, Name: p.Name}
+		switch {
+		case p.Quantity == nil:
+			// The same amount as the replaced ingredient.
+			r.Quantity, r.Unit = ing.Quantity, ing.Unit
+		case factor != 0:
+			// Keep the unit of the rule, which suits the amount of
+			// the replacement, unless the recipe uses another unit
+			// system. Then the unit of the recipe is used if possible,
+			// so that a rule written in cups gives millilitres in a
+			// metric recipe.
+			scaled := p.Quantity.Scale(factor)
+			if sys := scaled.Unit.System; sys != conversion.SystemAny && q.Unit.System != conversion.SystemAny && sys != q.Unit.System {
+				if in, err := scaled.In(q.Unit); err == nil {
+					scaled = in
+				} else {
+					scaled = scaled.To(q.Unit.System)
+				}
+			}
+			r.Quantity = conversion.FormatFloat(scaled.Value)
+			r.Unit = scaled.Unit.Symbol
+		}
+		s.Replacements = append(s.Replacements, r)
+	}
+	return s, true
+}
+
+// avoided returns true if the ingredient called name is one of avoid,
+// or a more specific kind of one of them so that avoiding "milk" also
+// avoids "whole milk".
+func (db *Database) avoided(name string, avoid []string) bool {
+	k := db.names.Key(name)
+	for _, a := range avoid {
+		ak := db.names.Key(a)
+		if ak != "" && strings.Contains(" "+k+" ", " "+ak+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,167 @@
+package substitution
+
+import (
+	"bytes"
+	_ "embed"
+	"github.com/dememorized/cook/aromalang"
+	"github.com/dememorized/cook/internal/cooklang"
+	"strings"
+	"testing"
+)
+
+//go:embed testdata/substitutions.txt
+var substitutions []byte
+
+//go:embed testdata/pancakes.cook
+var pancakes string
+
+func load(t *testing.T) *Database {
+	db, err := Load(bytes.NewReader(substitutions))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	db := load(t)
+	if db.Len() != 6 {
+		t.Errorf("expected 6 rules, got %d", db.Len())
+	}
+
+	mixed, err := Load(strings.NewReader("1 1/2 cup cream = 1 cup milk + 1/2 cup butter\n"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if rules := mixed.Lookup("cream"); len(rules) != 1 || rules[0].String() != "1.5 cup cream = 1 cup milk + 0.5 cup butter" {
+		t.Errorf("expected a rule with mixed numbers, got %v", rules)
+	}
+
+	rules := db.Lookup("Eggs")
+	if len(rules) != 2 || rules[0].String() != "1 egg = 1 tbsp ground flaxseed + 3 tbsp water" {
+		t.Errorf("expected the egg rules in order, got %v", rules)
+	}
+
+	for input, expected := range map[string]string{
+		"buttermilk\n":             "line 1: expected '=' between an ingredient and its substitutes",
+		"butter = 100 g lard\n":    "line 1: butter needs an amount to be replaced by 100 g lard",
+		"1 cup milk = 1 cup\n":     "line 1: missing ingredient after '1 cup'",
+		"# eggs\n0 egg = banana\n": "line 2: amount of egg must be positive",
+		"1 1/2 cup = cream\n":      "line 1: missing ingredient after '1 1/2 cup'",
+	} {
+		_, err := Load(strings.NewReader(input))
+		if err == nil || err.Error() != expected {
+			t.Errorf("%q: expected error '%s', got %v", input, expected, err)
+		}
+	}
+}
+
+func parse(t *testing.T) *aromalang.AST {
+	tokens, errs := cooklang.Tokenize("pancakes.cook", strings.NewReader(pancakes))
+	if len(errs) != 0 {
+		t.Error(errs)
+		t.FailNow()
+	}
+	ast, err := cooklang.Parse("pancakes.cook", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	return ast
+}
+
+func TestSuggest(t *testing.T) {
+	db := load(t)
+
+	suggestions := db.Suggest(parse(t), []string{"egg", "milk"})
+	list := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		list = append(list, s.String())
+	}
+
+	// Buttermilk isn't milk, but whole milk is. The rules for milk
+	// replace it with other kinds of milk, which is fine.
+	expected := []string{
+		"2 eggs → 2 tbsp ground flaxseed + 6 tbsp water",
+		"2 eggs → 0.5 cup apple sauce",
+		"2 dl whole milk → 2 dl oat milk",
+		"2 dl whole milk → 2 dl soy milk",
+	}
+	if strings.Join(list, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected suggestions:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(list, "\n"))
+	}
+
+	// The replacements keep the units of the rule within the same unit
+	// system, and are converted to the recipe's unit otherwise.
+	tokens, _ := cooklang.Tokenize("buttermilk.cook", strings.NewReader("Add @buttermilk{2%cups}, or @buttermilk{473%ml}.\n"))
+	ast, err := cooklang.Parse("buttermilk.cook", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	suggestions = db.Suggest(ast, []string{"buttermilk"})
+	if len(suggestions) != 2 || suggestions[0].String() != "2 cups buttermilk → 2 cup milk + 2 tbsp lemon juice" ||
+		suggestions[1].String() != "473 ml buttermilk → 473 ml milk + 29.56 ml lemon juice" {
+		t.Errorf("expected the amounts in the units of the rule, got %v", suggestions)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	db := load(t)
+	ast := parse(t)
+
+	res, applied, err := db.Rewrite(ast, []string{"buttermilk", "butter", "eggs"})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(applied) != 3 {
+		t.Errorf("expected three substitutions, got %v", applied)
+	}
+
+	expected := `>> servings: 4
+
+Whisk the @ground flaxseed{2%tbsp} and @water{6%tbsp} with the @milk{500%ml} and @lemon juice{31.25%ml} and @flour{250%g}. -- instead of 2 eggs and 500 ml buttermilk
+
+Fry in @margarine and serve with @whole milk{2%dl}. -- instead of butter
+`
+	printer := cooklang.Printer{KeepLines: true}
+	if out := printer.Format(res); out != expected {
+		t.Errorf("expected rewritten recipe:\n%s\ngot:\n%s", expected, out)
+	}
+	if ast.Recipe.Steps[0].Ingredients()[0].Name != "eggs" {
+		t.Errorf("expected the original recipe to be unchanged")
+	}
+
+	// The lines of the step are kept, with a comment at the end of
+	// each line that had a substitution.
+	tokens, _ := cooklang.Tokenize("cake.cook", strings.NewReader("Beat @butter{100%g} with @sugar{1%dl}, then fold.\nAdd @buttermilk{1%cup} -- cold\nand bake.\n"))
+	cake, err := cooklang.Parse("cake.cook", tokens)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	res, _, err = db.Rewrite(cake, []string{"butter", "buttermilk"})
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected = "Beat @margarine{100%g} with @sugar{1%dl}, then fold. -- instead of 100 g butter\n" +
+		"Add @milk{1%cup} and @lemon juice{1%tbsp} -- cold\n-- instead of 1 cup buttermilk\nand bake.\n"
+	if out := printer.Format(res); out != expected {
+		t.Errorf("expected rewritten recipe:\n%s\ngot:\n%s", expected, out)
+	}
+
+	// Buttermilk can only be replaced with milk.
+	_, _, err = db.Rewrite(ast, []string{"buttermilk", "milk"})
+	if err == nil || err.Error() != "no substitute for buttermilk" {
+		t.Errorf("expected buttermilk to have no substitute without milk, got %v", err)
+	}
+
+	_, _, err = db.Rewrite(ast, []string{"flour", "butter"})
+	if err == nil || err.Error() != "no substitute for flour" {
+		t.Errorf("expected flour to have no substitute, got %v", err)
+	}
+}